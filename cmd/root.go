@@ -1,8 +1,8 @@
 package cmd
 
 import (
-	"bufio"
 	"encoding/csv"
+	"errors"
 	"github.com/xztaityozx/sel/internal/iterator"
 	"github.com/xztaityozx/sel/internal/output"
 	"io"
@@ -12,9 +12,17 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/xztaityozx/sel/internal/archive"
+	"github.com/xztaityozx/sel/internal/charset"
 	"github.com/xztaityozx/sel/internal/column"
+	"github.com/xztaityozx/sel/internal/decompress"
+	"github.com/xztaityozx/sel/internal/expr"
+	"github.com/xztaityozx/sel/internal/filter"
+	"github.com/xztaityozx/sel/internal/jsonl"
 	"github.com/xztaityozx/sel/internal/option"
 	"github.com/xztaityozx/sel/internal/parser"
+	"github.com/xztaityozx/sel/internal/record"
+	"github.com/xztaityozx/sel/internal/rows"
 )
 
 var Version string = "undefined"
@@ -37,10 +45,25 @@ __sel__ect column`,
 		if err != nil {
 			log.Fatalln(err)
 		}
-		selectors, err := parser.Parse(args)
+		selectors, queries, err := parser.ParseNamed(args, opt.HasColumnNames())
 		if err != nil {
 			log.Fatalln(err)
 		}
+		// --output-format json や表、LTSV、logfmt、--emit-header のときはカラムにクエリかヘッダーの名前をつける
+		var names *columnNames
+		if opt.IsNamed() || opt.EmitHeader {
+			names = &columnNames{queries: queries}
+		}
+		f, err := filter.NewFilter(opt.Where, opt.InvertWhere)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		var rowSelector rows.Selector
+		if len(opt.Rows) != 0 {
+			if rowSelector, err = rows.NewSelector(opt.Rows); err != nil {
+				log.Fatalln(err)
+			}
+		}
 
 		w := output.NewWriter(opt, os.Stdout, false)
 
@@ -50,20 +73,28 @@ __sel__ect column`,
 				log.Fatalln(err)
 			}
 
-			for _, file := range files {
-				if fp, err := os.Open(file); err != nil {
-					log.Fatalln(err)
-				} else {
-					if err := run(fp, opt, w, selectors); err != nil {
-						log.Fatalln(err)
-					}
-				}
+			// zip や tar のアーカイブは、中のファイルを1つずつ別の入力として読む
+			err = archive.Walk(files, !opt.NoDecompress, func(r io.Reader) error {
+				return run(r, opt, w, selectors, names, f, rowSelector)
+			})
+			if err != nil {
+				log.Fatalln(err)
 			}
 		} else {
-			if err := run(os.Stdin, opt, w, selectors); err != nil {
+			if err := run(os.Stdin, opt, w, selectors, names, f, rowSelector); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		// すべての行が --where や --rows で選ばれなかったときも、--emit-header のヘッダー行は書き出す
+		if opt.EmitHeader {
+			if err := w.WriteHeader(names.queries...); err != nil {
 				log.Fatalln(err)
 			}
 		}
+		if err := w.Close(); err != nil {
+			log.Fatalln(err)
+		}
 	},
 }
 
@@ -74,7 +105,7 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.Flags().StringSliceP(option.NameInputFiles, "f", nil, "input files")
+	rootCmd.Flags().StringSliceP(option.NameInputFiles, "f", nil, "input files (members of zip/tar archives are read one by one, archive.zip:pattern selects members)")
 	rootCmd.Flags().StringP(option.NameInputDelimiter, "d", " ", "sets field delimiter(input)")
 	rootCmd.Flags().StringP(option.NameOutPutDelimiter, "D", " ", "sets field delimiter(output)")
 	rootCmd.Flags().BoolP(option.NameRemoveEmpty, "r", false, "remove empty sequence")
@@ -85,9 +116,53 @@ func init() {
 	rootCmd.Flags().StringP(option.NameFillMissing, "E", option.DefaultFillMissing, "fill value for out-of-range columns (implies -M)")
 	rootCmd.Flags().Bool(option.NameCsv, false, "parse input file as CSV")
 	rootCmd.Flags().Bool(option.NameTsv, false, "parse input file as TSV")
+	rootCmd.Flags().String(option.NameCsvDelimiter, "", "parse input as CSV delimited by the character (e.g. ';', '|', '\\t')")
+	rootCmd.Flags().String(option.NameCsvComment, "", "skip CSV/TSV lines starting with the character (e.g. '#')")
+	rootCmd.Flags().Bool(option.NameCsvLazyQuotes, false, "allow bare and unescaped quotes in CSV/TSV input")
+	rootCmd.Flags().Bool(option.NameCsvTrimSpace, false, "ignore leading white space of CSV/TSV columns")
+	rootCmd.Flags().Int(option.NameCsvFields, 0, "number of columns in each CSV/TSV line (0: same as the first line, negative: any)")
+	rootCmd.Flags().StringSlice(option.NameWidths, nil, "parse input as fixed-width columns of the widths ('-' for the rest of line, e.g. 5,10,3,-)")
+	rootCmd.Flags().StringSlice(option.NameCuts, nil, "parse input as fixed-width columns starting at the positions (e.g. 1,6,16)")
+	rootCmd.Flags().Bool(option.NameDisplayWidth, false, "count --widths/--cuts/--aligned by display width instead of characters")
+	rootCmd.Flags().Bool(option.NameJsonl, false, "parse each line as JSON and enable JSON path queries")
+	rootCmd.Flags().Bool(option.NameLtsv, false, "parse input as LTSV and enable label queries (output is LTSV unless -D is given)")
+	rootCmd.Flags().Bool(option.NameLogfmt, false, "parse input as logfmt and enable key queries (use --output-format logfmt for key=value output)")
+	rootCmd.Flags().Bool(option.NameQuoted, false, "split input by whitespace but not inside quotes, and remove quotes and backslash escapes from columns")
+	rootCmd.Flags().String(option.NameQuoteChars, option.DefaultQuoteChars, "quote characters for --quoted")
+	rootCmd.Flags().Bool(option.NameNoEscape, false, "treat backslash as a normal character with --quoted")
+	rootCmd.Flags().Bool(option.NameAligned, false, "split lines aligned by spaces (like ps, docker ps, df) at column positions of the header (implies --header)")
+	rootCmd.Flags().Int(option.NameAlignedSample, 0, "number of lines after the header used to refine --aligned column positions")
+	rootCmd.Flags().BoolP(option.NameNullData, "z", false, "separate input and output records by NUL instead of newline (e.g. for find -print0)")
+	rootCmd.Flags().String(option.NameRecordSep, "", "separate input records by the string, /regexp/ or blank lines if empty, where newlines also separate columns (\\n, \\t, \\0 are available)")
+	rootCmd.Flags().String(option.NameOutputRecordSep, "", "separate output records by the string instead of newline (\\n, \\t, \\0 are available)")
+	rootCmd.Flags().String(option.NameRecordStart, "", "join lines into a record until the next line matching /regexp/ (e.g. /^\\d{4}-\\d\\d-\\d\\d/)")
+	rootCmd.Flags().String(option.NameContinuation, "", "join lines matching /regexp/ to the previous record (e.g. /^\\s+/)")
+	rootCmd.Flags().String(option.NameRecordJoin, "", "string to put between lines joined by --record-start or --continuation (newline if not given)")
+	rootCmd.Flags().String(option.NameInputEncoding, "", "decode input from the encoding (shift_jis, euc-jp, iso-2022-jp, utf-16le, utf-16be, latin-1; BOM is detected and removed)")
+	rootCmd.Flags().String(option.NameOutputEncoding, "", "encode output to the encoding (same as --input-encoding, default utf-8)")
+	rootCmd.Flags().Bool(option.NameNoDecompress, false, "read gzip, bzip2 and zlib compressed input as is instead of decompressing it (.tar.gz and .tar.bz2 are not decompressed either)")
 	rootCmd.Flags().StringP(option.NameTemplate, "t", option.DefaultTemplate, "template for output")
+	rootCmd.Flags().String(option.NameOutputFormat, "", "output format (json: an array of rows, jsonl: a row per line, csv, tsv, table, markdown, github, ltsv, logfmt)")
+	rootCmd.Flags().Bool(option.NameInferTypes, false, "output numbers, true/false and null (empty) as JSON types instead of strings")
+	rootCmd.Flags().Bool(option.NameOutputCsv, false, "output as CSV (default with --csv unless -D is given)")
+	rootCmd.Flags().Bool(option.NameOutputTsv, false, "output as TSV (default with --tsv unless -D is given)")
+	rootCmd.Flags().String(option.NameOutputQuote, option.QuoteMinimal, "columns to be quoted in CSV/TSV output (minimal, all, non-numeric)")
+	rootCmd.Flags().Bool(option.NameCRLF, false, "use CRLF as line terminator in CSV/TSV output")
+	rootCmd.Flags().Int(option.NameTableSample, 0, "fix column widths of table/markdown/github output after the first N rows and stream the rest (0: read all rows)")
+	rootCmd.Flags().Bool(option.NameJSONObject, false, "output rows as JSON objects keyed by queries (default with --header, keyed by column names)")
+	rootCmd.Flags().Bool(option.NameHeader, false, "treat the first line as header and enable column name queries")
+	rootCmd.Flags().Bool(option.NameKeepHeader, false, "output the first line selected by queries regardless of --where and --rows (once for multiple files)")
+	rootCmd.Flags().Bool(option.NameEmitHeader, false, "output names of selected columns (names in header or queries) as the first line")
+	rootCmd.Flags().StringArray(option.NameWhere, nil, "select only lines where the expression is true, written like =( ) queries (e.g. '$3 =~ /ERROR/ && $5 > 100', multiple --where are AND-ed)")
+	rootCmd.Flags().Bool(option.NameInvertWhere, false, "select only lines not matching --where")
+	rootCmd.Flags().String(option.NameRows, "", "select only lines in the range (e.g. 10:20, ::2, -100:, /^BEGIN/:/^END/)")
 	_ = rootCmd.MarkFlagFilename(option.NameInputFiles)
 	rootCmd.MarkFlagsMutuallyExclusive(option.NameCsv, option.NameTsv)
+	rootCmd.MarkFlagsMutuallyExclusive(option.NameKeepHeader, option.NameEmitHeader)
+	rootCmd.MarkFlagsMutuallyExclusive(option.NameNullData, option.NameRecordSep)
+	rootCmd.MarkFlagsMutuallyExclusive(option.NameTemplate, option.NameOutputFormat, option.NameOutputCsv, option.NameOutputTsv)
+	rootCmd.MarkFlagsMutuallyExclusive(option.NameOutPutDelimiter, option.NameOutputFormat, option.NameOutputCsv, option.NameOutputTsv)
+	rootCmd.MarkFlagsMutuallyExclusive(option.NameWidths, option.NameCuts, option.NameAligned, option.NameJsonl, option.NameLtsv, option.NameLogfmt, option.NameQuoted, option.NameCsv, option.NameTsv)
 
 	for _, key := range option.GetOptionNames() {
 		_ = viper.BindPFlag(key, rootCmd.Flags().Lookup(key))
@@ -99,9 +174,35 @@ func init() {
 		"$ sel 1:10 -f ./file",
 		"$ cat /path/to/file.csv | sel -d, 1 2 3 4 -- -1 -2 -3 -4",
 		"$ cat /path/to/file.csv | sel --csv 1 2 3 4",
+		"$ cat /path/to/sjis.csv | sel --csv --input-encoding shift_jis --output-encoding shift_jis 1 3",
+		"$ cat /path/to/excel.csv | sel --csv-delimiter ';' --csv-comment '#' --csv-fields-per-record -1 -- 1 -1",
+		"$ cat /path/to/file.txt | sel --output-csv --output-quote non-numeric --crlf 1 2 3",
+		"$ cat /path/to/report.txt | sel --widths 5,10,3,- -- 2 -1",
+		"$ docker ps | sel --aligned --aligned-sample 20 NAMES STATUS",
+		"$ cat /path/to/file.jsonl | sel --jsonl .user.id '.items[0].sku' '.tags[]'",
+		"$ cat /path/to/access.ltsv | sel --ltsv host status '/^req/'",
+		"$ cat /path/to/app.log | sel --logfmt --output-format logfmt level msg '/^dur/'",
+		"$ cat /path/to/args.txt | sel --quoted -- 1 -1",
 		"$ sel 2:: -f ./file",
+		"$ sel --csv --header user_id name -f ./2024-01.csv.gz -f ./2024-02.csv.bz2",
+		"$ sel --csv --keep-header 1 3 -f './bundle.zip:reports/*.csv' -f ./logs.tar.gz",
+		"$ find . -name '*.log' -print0 | sel -z -d / -- -1",
+		"$ cat /path/to/app.log | sel --record-start '/^\\d{4}-\\d\\d-\\d\\d/' --record-join ' | ' 1 2 3:",
+		"$ cat /path/to/records.txt | sel --record-separator '' --output-record-separator '\\n---\\n' 1 2",
 		"$ cat /path/to/file | sel /^begin/:/^end/",
 		"$ echo AAA BBB CCC | sel --template 'one: {} two: {} three: {}' 1 2 3",
+		"$ cat /path/to/file | sel '!3' '!7'",
+		"$ cat /path/to/file | sel '3|upper' '5|trim' '7|substr(0,8)'",
+		"$ echo 'a b,c,d e' | sel '2[,]2' '2{d=\",\"}.2:3'",
+		"$ cat /path/to/access.log | sel '4[2:12]' '0[-20:]' '7[w1:20]'",
+		"$ cat /path/to/file.csv | sel --csv --header user_id name:-1",
+		"$ cat /path/to/file.csv | sel --csv --header user_id @/^metric_/",
+		"$ sel --csv --keep-header --where '$3 > 100' 1 3 -f ./2023.csv -f ./2024.csv",
+		"$ cat /path/to/file | sel --where '$3 =~ /ERROR/' --where '$5 > 100' 1 2",
+		"$ cat /path/to/file | sel --rows -100: 1 2",
+		"$ cat /path/to/file.csv | sel --csv --header --output-format jsonl --infer-types id name score",
+		"$ cat /path/to/file.csv | sel --csv --header --output-format markdown id name score",
+		"$ cat /path/to/file | sel 1 '=( $3 * 1000 )' '=( $2 . \"-\" . $4 )' '=( len($5) )'",
 	}
 
 	rootCmd.Example = strings.Join(examples, "\n\t")
@@ -118,6 +219,47 @@ Query:
 	/start regexp/:end           select columns from /start regexp/ to 'end'
 	/start regexp/:/end regexp/  select columns from /start regexp/ to /end regexp/
 
+	!query                       select columns except 'query' (consecutive !queries are merged)
+
+	query[delim]query            split columns selected by the first 'query' by 'delim' and select by the second 'query'
+	query[/regexp/]query         same as above but split by /regexp/
+	query{d="delim"}.query       same as query[delim]query (d=/regexp/ is also available)
+
+	query[start:stop:step]       cut characters from 'start' to 'stop' out of columns selected by 'query' (0[1:8] for the line)
+	query[index]                 cut a character at 'index' out of columns selected by 'query'
+	                             [b...] counts bytes and [w...] counts display width instead of characters
+
+	query|pipe|pipe...           transform each selected column by pipes
+	                             pipes: upper lower trim trim(chars) replace(old,new) substr(start,length)
+	                                    pad(width,char) default(value) sha256 base64 urlencode
+
+	name                         select column 'name' in header (requires --header)
+	name:name:step               select columns between names in header (can be mixed with index)
+	@/regexp/                    select all columns whose name in header matches /regexp/ (requires --header)
+	/regexp/                     same as @/regexp/
+
+	label, label:label, /regexp/ select columns by label with --ltsv or key with --logfmt/--jsonl (resolved for each line)
+
+	.path                        select values by JSON path (requires --jsonl). index selects top-level values in order
+	                             .key, ."key", [N]: array element (0-indexed), []: all elements
+
+	=( expression )              output the result of 'expression' as a column
+	                             $3, $-1, $name: column, + - * / %: arithmetic, .: concatenation
+	                             == != < <= > >= (numeric if both are numbers) =~ !~ (/regexp/) && || !: comparison and logic
+	                             functions: len upper lower trim contains replace substr int floor ceil abs round min max if
+
+Where:
+	expression                   same as =( expression ), lines where the result is true are selected
+	                             a bare index or name on the left of a comparison is a column: 3 =~ /ERROR/ is $3 =~ /ERROR/
+	                             e.g. '3 =~ /ERROR/', '5 > 100', 'status == "ok" && -1 != ""'
+	                             a condition on a column missing from the line is false unless -M/-E is given
+
+Rows:
+	index                        select the 'index'th line (counted per file, excluding header)
+	start:stop:step              select lines each 'step' from 'start' to 'stop' (negative index counts from the end)
+	/start regexp/:/end regexp/  select lines from /start regexp/ to /end regexp/ (like sed)
+	/start regexp/:+N            select lines matching /start regexp/ and N lines after them
+
 Examples:
 {{.Example}}{{if .HasAvailableSubCommands}}
 
@@ -137,27 +279,119 @@ Use "{{.CommandPath}} [command] --help" for more information about a command.{{e
 `)
 }
 
-// run はあるファイルについて column.Selector によるカラム選択と column.Writer による書き出しを行う。ファイルはCloseされる
-func run(input *os.File, option option.Option, w *output.Writer, selectors []column.Selector) error {
-	defer func(input *os.File) {
-		if err := input.Close(); err != nil {
-			log.Fatalln(err)
-		}
-	}(input)
-
-	iter, err := iterator.NewIEnumerable(option)
+// run はある入力について filter.Filter による行の選択、 column.Selector によるカラム選択と column.Writer による書き出しを行う
+// names が nil でなければ、書き出すカラムに名前をつける
+func run(input io.Reader, option option.Option, w *output.Writer, selectors []column.Selector, names *columnNames, f filter.Filter, rowSelector rows.Selector) error {
+	iter, err := newIEnumerable(option)
 	if err != nil {
 		return err
 	}
 
+	// gzip や bzip2 で圧縮された入力は展開しながら読む
+	var raw io.Reader = input
+	if !option.NoDecompress {
+		if raw, err = decompress.NewReader(input); err != nil {
+			return err
+		}
+	}
+
+	// --input-encoding の入力や BOM つきの入力は、分割する前に BOM を取り除いて UTF-8 にしておく
+	decoded := charset.NewReader(raw, option.InputEncoding)
+
 	var fillMissing *string
 	if option.IgnoreMissing {
 		fillMissing = &option.FillMissing
 	}
 
+	// --header のときは最初の行をヘッダーとして読んで、カラム名のクエリを解決する
+	// 入力ファイルごとにカラムの並びが違うかもしれないので、解決はファイルごとに行う
+	// --keep-header のときは最初の行を --where や --rows によらずに書き出す。2つ目以降のファイルのヘッダー行は読み飛ばす
+	needHeader := option.UseHeader || option.KeepHeader
+	process := func() error {
+		if needHeader {
+			needHeader = false
+			if option.UseHeader {
+				header := column.NewHeader(iter.ToArray())
+				resolved, err := column.Resolve(selectors, header)
+				if err != nil {
+					return err
+				}
+				selectors = resolved
+				if names != nil {
+					names.header = header.Names()
+				}
+
+				if f, err = f.Resolve(header); err != nil {
+					return err
+				}
+
+				// --emit-header のヘッダー行は、データの行を待たずに解決した selectors から作る
+				if option.EmitHeader {
+					if err := w.WriteHeader(names.headerNames(selectors, iter)...); err != nil {
+						return err
+					}
+				}
+			}
+			if option.KeepHeader && w.TakeHeader() {
+				return selectAll(&iter, w, selectors, names, fillMissing)
+			}
+			return nil
+		}
+
+		if ok, err := f.Match(iter, fillMissing); err != nil || !ok {
+			return err
+		}
+		return selectAll(&iter, w, selectors, names, fillMissing)
+	}
+
+	// --rows のときは選ばれた行だけを処理する。行はヘッダーを除いてファイルごとに数える
+	if rowSelector != nil {
+		rowSelector.Reset()
+	}
+	handle := func(rec rows.Record) error {
+		if rec.Fields != nil {
+			iter.ResetFromArray(rec.Fields)
+		} else {
+			iter.Reset(rec.Line)
+		}
+		// --jsonl のようにパースが必要な入力では、パースできない行はエラーにする
+		if s, ok := iter.(jsonl.Documenter); ok {
+			if _, err := s.Document(); err != nil {
+				return err
+			}
+		}
+		return process()
+	}
+	// feed は1行を処理する。残りの行を読む必要がなければ true を返す
+	feed := func(rec rows.Record) (bool, error) {
+		if rowSelector == nil || needHeader {
+			return false, handle(rec)
+		}
+		for _, v := range rowSelector.Push(rec) {
+			if err := handle(v); err != nil {
+				return false, err
+			}
+		}
+		return rowSelector.Done(), nil
+	}
+	flush := func() error {
+		if rowSelector != nil {
+			for _, v := range rowSelector.Flush() {
+				if err := handle(v); err != nil {
+					return err
+				}
+			}
+		}
+		return w.Flush()
+	}
+
 	if ok, comma := option.IsXsv(); ok {
-		r := csv.NewReader(input)
+		r := csv.NewReader(decoded)
 		r.Comma = comma
+		r.Comment = option.Comment
+		r.LazyQuotes = option.LazyQuotes
+		r.TrimLeadingSpace = option.TrimLeadingSpace
+		r.FieldsPerRecord = option.FieldsPerRecord
 
 		var record []string
 		var csvReadError error
@@ -170,43 +404,99 @@ func run(input *os.File, option option.Option, w *output.Writer, selectors []col
 				break
 			}
 
-			iter.ResetFromArray(record)
-
-			if err := selectAll(&iter, w, selectors, fillMissing); err != nil {
-				return err
+			rec := rows.Record{Fields: record}
+			if rowSelector != nil {
+				// /regexp/ とマッチさせるために、カラムを区切り文字で連結した行も作っておく
+				rec.Line = strings.Join(record, string(comma))
+			}
+			if done, err := feed(rec); err != nil || done {
+				if err != nil {
+					return err
+				}
+				break
 			}
 		}
 
-		return w.Flush()
+		return flush()
 	}
 
-	reader := bufio.NewReader(input)
-	for {
-		line, err := reader.ReadString('\n')
-		if len(line) > 0 {
-			iter.Reset(strings.TrimRight(line, "\n"))
-			if err := selectAll(&iter, w, selectors, fillMissing); err != nil {
+	reader := record.NewReader(decoded, option.RecordOption)
+	if option.Aligned {
+		// --aligned のときはヘッダーとその後の何行かを先に読んで、カラムの位置を決める
+		lines, err := readLines(reader, 1+option.AlignedSample)
+		if err != nil {
+			return err
+		}
+		if len(lines) != 0 {
+			iter = iterator.NewAlignedIterator(lines[0], lines[1:], option.DisplayWidth, option.RemoveEmpty)
+		}
+		for _, line := range lines {
+			done, err := feed(rows.Record{Line: line})
+			if err != nil {
 				return err
 			}
+			if done {
+				return flush()
+			}
 		}
+	}
+
+	for {
+		line, err := reader.Read()
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
 			return err
 		}
+		done, err := feed(rows.Record{Line: line})
+		if err != nil {
+			return err
+		}
+		if done {
+			break
+		}
+	}
+
+	return flush()
+}
+
+// newIEnumerable は iterator.NewIEnumerable と同じで、--jsonl のときは JSON をパースする jsonl.Iterator を返す
+func newIEnumerable(option option.Option) (iterator.IEnumerable, error) {
+	if option.Jsonl {
+		return jsonl.NewIterator(""), nil
 	}
+	return iterator.NewIEnumerable(option)
+}
 
-	return w.Flush()
+// readLines は reader から最大 n 行を読んで返す
+func readLines(reader *record.Reader, n int) ([]string, error) {
+	var lines []string
+	for len(lines) < n {
+		line, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
 }
 
-func selectAll(iter *iterator.IEnumerable, w *output.Writer, selectors []column.Selector, fillMissing *string) error {
-	for _, selector := range selectors {
-		err := selector.Select(w, *iter)
+func selectAll(iter *iterator.IEnumerable, w *output.Writer, selectors []column.Selector, names *columnNames, fillMissing *string) error {
+	for i, selector := range selectors {
+		var err error
+		if names != nil {
+			err = names.selectNamed(w, i, selector, *iter)
+		} else {
+			err = selector.Select(w, *iter)
+		}
 		if err != nil {
-			if fillMissing != nil && err.Error() == iterator.IndexOutOfRange {
+			if fillMissing != nil && isMissing(err) {
 				if *fillMissing != "" {
-					if werr := w.Write(*fillMissing); werr != nil {
+					if werr := w.WriteNamed(names.name(i), *fillMissing); werr != nil {
 						return werr
 					}
 				}
@@ -217,3 +507,83 @@ func selectAll(iter *iterator.IEnumerable, w *output.Writer, selectors []column.
 	}
 	return w.WriteNewLine()
 }
+
+// isMissing は -M/-E で埋める値に置き換えてよいエラーかどうか
+func isMissing(err error) bool {
+	return err.Error() == iterator.IndexOutOfRange || errors.Is(err, expr.ErrNotNumber)
+}
+
+// columnNames は --output-format json のオブジェクトのキーや、表のヘッダー行、LTSV のラベルにするカラムの名前を決めるやつ
+type columnNames struct {
+	// queries はそれぞれの column.Selector のもとになったクエリ
+	queries []string
+	// header は --header のときのヘッダー行のカラム名
+	header []string
+}
+
+// name は i 番目の column.Selector が選んだカラムの名前を返す
+func (n *columnNames) name(i int) string {
+	if n == nil {
+		return ""
+	}
+	return n.queries[i]
+}
+
+// headerNames は --header のヘッダー行 iter で selectors が選ぶカラムの名前を、--emit-header のヘッダー行として返す
+// selectNamed と同じように、そのまま入力のカラムを選ぶならそのカラム名を、そうでなければクエリを名前にする
+func (n *columnNames) headerNames(selectors []column.Selector, iter iterator.IEnumerable) []string {
+	var rt []string
+	for i, s := range selectors {
+		indexer, ok := s.(column.Indexer)
+		if !ok {
+			rt = append(rt, n.queries[i])
+			continue
+		}
+		indexes, err := indexer.Indexes(iter)
+		if err != nil {
+			rt = append(rt, n.queries[i])
+			continue
+		}
+		for _, idx := range indexes {
+			name := n.queries[i]
+			if 0 < idx && idx <= len(n.header) {
+				name = n.header[idx-1]
+			}
+			rt = append(rt, name)
+		}
+	}
+	return rt
+}
+
+// selectNamed は i 番目の column.Selector で選んだカラムに名前をつけて書き出す
+// ヘッダーかラベルがあって、選んだカラムがそのまま入力のカラムならそのカラム名を、そうでなければクエリを名前にする
+func (n *columnNames) selectNamed(w *output.Writer, i int, s column.Selector, iter iterator.IEnumerable) error {
+	w.Capture()
+	err := s.Select(w, iter)
+	values := w.Release()
+	if err != nil {
+		return err
+	}
+
+	header := n.header
+	if l, ok := iter.(iterator.Labeled); ok {
+		header = l.Labels()
+	}
+
+	if indexer, ok := s.(column.Indexer); ok && header != nil {
+		if indexes, err := indexer.Indexes(iter); err == nil && len(indexes) == len(values) {
+			for k, idx := range indexes {
+				name := n.queries[i]
+				if 0 < idx && idx <= len(header) {
+					name = header[idx-1]
+				}
+				if err := w.WriteNamed(name, values[k]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	return w.WriteNamed(n.queries[i], values...)
+}
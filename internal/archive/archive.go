@@ -0,0 +1,184 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/xztaityozx/sel/internal/decompress"
+)
+
+// Separator はアーカイブのパスと、その中のファイルのパターンを区切る文字。bundle.zip:reports/*.csv のように書く
+const Separator = ":"
+
+// zipExtensions と tarExtensions はアーカイブとして読むファイルの拡張子
+var (
+	zipExtensions = []string{".zip"}
+	tarExtensions = []string{".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tbz"}
+)
+
+func hasExtension(p string, extensions []string) bool {
+	lower := strings.ToLower(p)
+	for _, ext := range extensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsArchive は p が zip か tar のアーカイブかどうかを拡張子で判断する
+func IsArchive(p string) bool {
+	return hasExtension(p, zipExtensions) || hasExtension(p, tarExtensions)
+}
+
+// Split は bundle.zip:reports/*.csv をアーカイブのパスと中のファイルのパターンに分ける
+// アーカイブの拡張子のすぐ後に : がなければ ok は false
+func Split(spec string) (archivePath, pattern string, ok bool) {
+	for i := range spec {
+		if strings.HasPrefix(spec[i:], Separator) && IsArchive(spec[:i]) {
+			return spec[:i], spec[i+len(Separator):], true
+		}
+	}
+	return spec, "", false
+}
+
+// Walk は files を順番に開いて f を呼ぶ。アーカイブなら中のファイルを1つずつ f に渡す
+// アーカイブのパスの後に :pattern があれば、path.Match でマッチするファイルだけを渡す
+// アーカイブの中のファイルで f がエラーを返したときは、どのファイルかがわかるようにエラーに名前をつける
+// decompressTar が false のときは .tar.gz や .tar.bz2 も展開せずに tar として読む
+func Walk(files []string, decompressTar bool, f func(r io.Reader) error) error {
+	for _, file := range files {
+		p, pattern, _ := Split(file)
+		var err error
+		switch {
+		case hasExtension(p, zipExtensions):
+			err = walkZip(p, pattern, f)
+		case hasExtension(p, tarExtensions):
+			err = walkTar(p, pattern, decompressTar, f)
+		default:
+			err = walkFile(p, f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkFile(p string, f func(r io.Reader) error) error {
+	fp, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = fp.Close() }()
+	return f(fp)
+}
+
+// match はアーカイブの中のファイル name が pattern にマッチするかどうかを返す。pattern が空ならすべてにマッチする
+func match(pattern, name string) (bool, error) {
+	if len(pattern) == 0 {
+		return true, nil
+	}
+	return path.Match(strings.TrimPrefix(pattern, "./"), strings.TrimPrefix(path.Clean(name), "./"))
+}
+
+func walkZip(p, pattern string, f func(r io.Reader) error) error {
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p, err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	found := false
+	for _, member := range zr.File {
+		if !member.Mode().IsRegular() {
+			continue
+		}
+		if ok, err := match(pattern, member.Name); err != nil || !ok {
+			if err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+			continue
+		}
+
+		found = true
+		if err := walkZipMember(p, member, f); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return noMembers(p, pattern)
+	}
+	return nil
+}
+
+func walkZipMember(p string, member *zip.File, f func(r io.Reader) error) error {
+	r, err := member.Open()
+	if err != nil {
+		return fmt.Errorf("%s%s%s: %w", p, Separator, member.Name, err)
+	}
+	defer func() { _ = r.Close() }()
+	if err := f(r); err != nil {
+		return fmt.Errorf("%s%s%s: %w", p, Separator, member.Name, err)
+	}
+	return nil
+}
+
+func walkTar(p, pattern string, decompressTar bool, f func(r io.Reader) error) error {
+	fp, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = fp.Close() }()
+
+	// .tar.gz や .tar.bz2 は展開しながら読む
+	var r io.Reader = fp
+	if decompressTar {
+		if r, err = decompress.NewReader(fp); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+	}
+
+	tr := tar.NewReader(r)
+	found := false
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		if ok, err := match(pattern, header.Name); err != nil || !ok {
+			if err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+			continue
+		}
+
+		found = true
+		if err := f(tr); err != nil {
+			return fmt.Errorf("%s%s%s: %w", p, Separator, header.Name, err)
+		}
+	}
+	if !found {
+		return noMembers(p, pattern)
+	}
+	return nil
+}
+
+func noMembers(p, pattern string) error {
+	if len(pattern) == 0 {
+		return fmt.Errorf("%s: there are no files in the archive", p)
+	}
+	return fmt.Errorf("%s: no files match %s in the archive", p, pattern)
+}
@@ -0,0 +1,140 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		spec        string
+		wantPath    string
+		wantPattern string
+		wantOk      bool
+	}{
+		{spec: "bundle.zip:reports/*.csv", wantPath: "bundle.zip", wantPattern: "reports/*.csv", wantOk: true},
+		{spec: "dir:x/logs.TAR.GZ:*.log", wantPath: "dir:x/logs.TAR.GZ", wantPattern: "*.log", wantOk: true},
+		{spec: "bundle.zip:", wantPath: "bundle.zip", wantPattern: "", wantOk: true},
+		{spec: "bundle.zip", wantPath: "bundle.zip", wantOk: false},
+		{spec: `C:\data\file.csv`, wantPath: `C:\data\file.csv`, wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			p, pattern, ok := Split(tt.spec)
+			assert.Equal(t, tt.wantPath, p)
+			assert.Equal(t, tt.wantPattern, pattern)
+			assert.Equal(t, tt.wantOk, ok)
+		})
+	}
+}
+
+// members はテスト用のアーカイブに入れるファイル。ディレクトリは読み飛ばされるべき
+var members = []struct {
+	name    string
+	content string
+}{
+	{"reports/", ""},
+	{"reports/a.csv", "a\n"},
+	{"reports/b.csv", "b\n"},
+	{"readme.txt", "readme\n"},
+}
+
+func writeZip(t *testing.T, p string) {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, m := range members {
+		w, err := zw.Create(m.name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(m.content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, os.WriteFile(p, buf.Bytes(), 0644))
+}
+
+// writeTar は tar のアーカイブを書く。gzipped なら gzip で圧縮する
+func writeTar(t *testing.T, p string, gzipped bool) {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	var w io.Writer = buf
+	gw := gzip.NewWriter(buf)
+	if gzipped {
+		w = gw
+	}
+	tw := tar.NewWriter(w)
+	for _, m := range members {
+		header := &tar.Header{Name: "./" + m.name, Mode: 0644, Size: int64(len(m.content)), Typeflag: tar.TypeReg}
+		if m.content == "" {
+			header.Typeflag = tar.TypeDir
+		}
+		assert.NoError(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(m.content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	if gzipped {
+		assert.NoError(t, gw.Close())
+	}
+	assert.NoError(t, os.WriteFile(p, buf.Bytes(), 0644))
+}
+
+func walkAll(files []string, decompressTar bool) ([]string, error) {
+	var got []string
+	err := Walk(files, decompressTar, func(r io.Reader) error {
+		b, err := io.ReadAll(r)
+		got = append(got, string(b))
+		return err
+	})
+	return got, err
+}
+
+func TestWalk(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "bundle.zip")
+	tarPath := filepath.Join(dir, "bundle.tar.gz")
+	plainTarPath := filepath.Join(dir, "bundle.tar")
+	plainPath := filepath.Join(dir, "plain.csv")
+	writeZip(t, zipPath)
+	writeTar(t, tarPath, true)
+	writeTar(t, plainTarPath, false)
+	assert.NoError(t, os.WriteFile(plainPath, []byte("plain\n"), 0644))
+
+	tests := []struct {
+		name         string
+		files        []string
+		noDecompress bool
+		want         []string
+		wantErr      bool
+	}{
+		{name: "plain file", files: []string{plainPath}, want: []string{"plain\n"}},
+		{name: "all members of zip", files: []string{zipPath}, want: []string{"a\n", "b\n", "readme\n"}},
+		{name: "zip with pattern", files: []string{zipPath + ":reports/*.csv"}, want: []string{"a\n", "b\n"}},
+		{name: "tar.gz with pattern", files: []string{tarPath + ":reports/*.csv", plainPath}, want: []string{"a\n", "b\n", "plain\n"}},
+		{name: "no members match", files: []string{zipPath + ":*.json"}, wantErr: true},
+		{name: "bad pattern", files: []string{tarPath + ":["}, wantErr: true},
+		{name: "tar without decompression", files: []string{plainTarPath + ":reports/a.csv"}, noDecompress: true, want: []string{"a\n"}},
+		{name: "tar.gz without decompression", files: []string{tarPath}, noDecompress: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := walkAll(tt.files, !tt.noDecompress)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package charset
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// NewReader は enc の入力を UTF-8 にして読む io.Reader を返す。enc が nil なら UTF-8 の入力として扱う
+// 先頭に BOM があれば取り除いて、UTF-16 の BOM なら enc によらず UTF-16 として読む
+func NewReader(r io.Reader, enc encoding.Encoding) io.Reader {
+	if enc != nil {
+		return transform.NewReader(r, unicode.BOMOverride(enc.NewDecoder()))
+	}
+
+	// UTF-8 のときはほとんど BOM がないので、変換を挟まずに先頭だけ見る
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(len(utf8BOM))
+	switch {
+	case bytes.HasPrefix(head, utf8BOM):
+		_, _ = br.Discard(len(utf8BOM))
+	case bytes.HasPrefix(head, utf16LEBOM), bytes.HasPrefix(head, utf16BEBOM):
+		return transform.NewReader(br, unicode.BOMOverride(transform.Nop))
+	}
+	return br
+}
+
+// NewWriter は UTF-8 の出力を enc にして w に書き込む io.WriteCloser を返す。enc が nil なら nil を返す
+// enc で表せない文字は enc の代わりの文字(Shift_JIS なら 0x1A)にする。最後まで書き込むには Close する必要がある
+func NewWriter(w io.Writer, enc encoding.Encoding) io.WriteCloser {
+	if enc == nil {
+		return nil
+	}
+	return transform.NewWriter(w, encoding.ReplaceUnsupported(enc.NewEncoder()))
+}
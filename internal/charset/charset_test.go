@@ -0,0 +1,55 @@
+package charset
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestNewReader(t *testing.T) {
+	utf16le := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	tests := []struct {
+		name  string
+		input string
+		enc   encoding.Encoding
+		want  string
+	}{
+		{name: "UTF-8", input: "id,名前\n1,太郎\n", want: "id,名前\n1,太郎\n"},
+		{name: "UTF-8 with BOM", input: "\xEF\xBB\xBFid,名前\n", want: "id,名前\n"},
+		{name: "UTF-16LE with BOM", input: "\xFF\xFEi\x00d\x00\n\x00", want: "id\n"},
+		{name: "UTF-16BE with BOM", input: "\xFE\xFF\x00i\x00d\x00\n", want: "id\n"},
+		{name: "UTF-16LE without BOM", input: "i\x00d\x00\n\x00", enc: utf16le, want: "id\n"},
+		{name: "Shift_JIS", input: "\x96\xbc\x91\x4f 1\n", enc: japanese.ShiftJIS, want: "名前 1\n"},
+		{name: "Shift_JIS with UTF-8 BOM", input: "\xEF\xBB\xBF名前", enc: japanese.ShiftJIS, want: "名前"},
+		{name: "EUC-JP", input: "\xcc\xbe\xc1\xb0", enc: japanese.EUCJP, want: "名前"},
+		{name: "ISO-2022-JP", input: "\x1b$BL>A0\x1b(B a", enc: japanese.ISO2022JP, want: "名前 a"},
+		{name: "Latin-1", input: "caf\xe9", enc: charmap.ISO8859_1, want: "café"},
+		{name: "short input", input: "a", want: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(NewReader(strings.NewReader(tt.input), tt.enc))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	assert.Nil(t, NewWriter(&bytes.Buffer{}, nil), "UTF-8 のときは変換しないべき")
+
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf, japanese.ShiftJIS)
+	_, err := w.Write([]byte("名前 😀\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "\x96\xbc\x91\x4f \x1a\n", buf.String(), "Shift_JIS で表せない文字は置き換えるべき")
+}
@@ -0,0 +1,92 @@
+package column
+
+import (
+	"errors"
+
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/output"
+)
+
+// ComplementSelector は excludes で選択されるカラム以外を選択するやつ
+// 行ごとにカラム数を見て補集合を作るので、カラム数がばらばらな入力でもちゃんと動く
+type ComplementSelector struct {
+	excludes []Indexer
+}
+
+func NewComplementSelector(excludes ...Indexer) ComplementSelector {
+	return ComplementSelector{excludes: excludes}
+}
+
+// Select は excludes で選択されないカラムを元の順番のまま書き出す
+func (c ComplementSelector) Select(w *output.Writer, iter iterator.IEnumerable) error {
+	strings := iter.ToArray()
+	indexes, err := c.indexes(iter, len(strings))
+	if err != nil {
+		return err
+	}
+
+	rt := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		rt = append(rt, strings[i-1])
+	}
+
+	return w.Write(rt...)
+}
+
+func (c ComplementSelector) Indexes(iter iterator.IEnumerable) ([]int, error) {
+	return c.indexes(iter, len(iter.ToArray()))
+}
+
+// indexes は m 個のカラムのうち excludes で選択されないものの index を返す
+func (c ComplementSelector) indexes(iter iterator.IEnumerable, m int) ([]int, error) {
+	excluded := make([]bool, m)
+
+	for _, e := range c.excludes {
+		indexes, err := e.Indexes(iter)
+		if err != nil {
+			// 存在しないカラムを除外しようとしても、除外するものがないだけなので無視する
+			if err.Error() == iterator.IndexOutOfRange {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, i := range indexes {
+			if i == 0 {
+				// 0 は行全体なので、カラムの補集合としては意味を持たない
+				return nil, errors.New("index 0 cannot be used in complement query")
+			}
+			if 1 <= i && i <= m {
+				excluded[i-1] = true
+			}
+		}
+	}
+
+	rt := make([]int, 0, m)
+	for i, e := range excluded {
+		if !e {
+			rt = append(rt, i+1)
+		}
+	}
+	return rt, nil
+}
+
+// Resolve は excludes のうち Resolver なものをヘッダーで解決する
+func (c ComplementSelector) Resolve(h Header) (Selector, error) {
+	excludes := make([]Indexer, 0, len(c.excludes))
+	for _, e := range c.excludes {
+		r, ok := e.(Resolver)
+		if !ok {
+			excludes = append(excludes, e)
+			continue
+		}
+
+		s, err := r.Resolve(h)
+		if err != nil {
+			return nil, err
+		}
+		excludes = append(excludes, s.(Indexer))
+	}
+
+	return NewComplementSelector(excludes...), nil
+}
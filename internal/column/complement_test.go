@@ -0,0 +1,76 @@
+package column
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/option"
+	"github.com/xztaityozx/sel/internal/output"
+)
+
+func TestComplementSelector_Select(t *testing.T) {
+	newSwitch := func(begin, end string) SwitchSelector {
+		s, _ := NewSwitchSelector(begin, end)
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		excludes []Indexer
+		line     string
+		want     string
+		wantErr  bool
+	}{
+		{name: "!3", excludes: []Indexer{NewIndexSelector(3)}, line: "1 2 3 4 5", want: "1 2 4 5"},
+		{name: "!3 !7", excludes: []Indexer{NewIndexSelector(3), NewIndexSelector(7)}, line: "1 2 3 4 5 6 7 8", want: "1 2 4 5 6 8"},
+		{name: "!-1", excludes: []Indexer{NewIndexSelector(-1)}, line: "1 2 3", want: "1 2"},
+		{name: "!2:4", excludes: []Indexer{NewRangeSelector(2, 1, 4, false)}, line: "1 2 3 4 5", want: "1 5"},
+		{name: "!1::2", excludes: []Indexer{NewRangeSelector(1, 2, 1, true)}, line: "1 2 3 4 5", want: "2 4"},
+		{name: "!/b/:/d/", excludes: []Indexer{newSwitch("/b/", "/d/")}, line: "a b c d e", want: "a e"},
+		{name: "!/c/:+1", excludes: []Indexer{newSwitch("/c/", "+1")}, line: "a b c d e", want: "a b e"},
+		{name: "out of range is ignored", excludes: []Indexer{NewIndexSelector(10)}, line: "1 2 3", want: "1 2 3"},
+		{name: "overlapping", excludes: []Indexer{NewRangeSelector(1, 1, 3, false), NewIndexSelector(2)}, line: "1 2 3 4", want: "4"},
+		{name: "!0", excludes: []Indexer{NewIndexSelector(0)}, line: "1 2 3", wantErr: true},
+		{name: "!0:2", excludes: []Indexer{NewRangeSelector(0, 1, 2, false)}, line: "1 2 3", wantErr: true},
+		{name: "!-10:", excludes: []Indexer{NewRangeSelector(-10, 1, 1, true)}, line: "1 2 3", want: ""},
+		{name: "!-10:2", excludes: []Indexer{NewRangeSelector(-10, 1, 2, false)}, line: "1 2 3", want: "3"},
+		{name: "!-10:-8", excludes: []Indexer{NewRangeSelector(-10, 1, -8, false)}, line: "1 2 3", want: "1 2 3"},
+		{name: "!-10:-3", excludes: []Indexer{NewRangeSelector(-10, 1, -3, false)}, line: "1 2 3", want: "2 3"},
+		{name: "!-2:-10:-1", excludes: []Indexer{NewRangeSelector(-2, -1, -10, false)}, line: "1 2 3", want: "3"},
+		{name: "!-10:-20:-1", excludes: []Indexer{NewRangeSelector(-10, -1, -20, false)}, line: "1 2 3", want: "1 2 3"},
+		{name: "!2:10 on a short line", excludes: []Indexer{NewRangeSelector(2, 1, 10, false)}, line: "a", want: "a"},
+		{name: "!4: on a short line", excludes: []Indexer{NewRangeSelector(4, 1, 1, true)}, line: "a b c", want: "a b c"},
+		{name: "!2:10 clamps stop", excludes: []Indexer{NewRangeSelector(2, 1, 10, false)}, line: "a b c", want: "a"},
+		{name: "!10:2:-1 clamps start", excludes: []Indexer{NewRangeSelector(10, -1, 2, false)}, line: "a b c", want: "a"},
+		{name: "!10:5:-1 on a short line", excludes: []Indexer{NewRangeSelector(10, -1, 5, false)}, line: "a b c", want: "a b c"},
+		{name: "invalid step", excludes: []Indexer{NewRangeSelector(1, -1, 3, false)}, line: "1 2 3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, buf, false)
+			err := NewComplementSelector(tt.excludes...).Select(w, iterator.NewIterator(tt.line, " ", false))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestComplementSelector_Resolve(t *testing.T) {
+	h := NewHeader([]string{"id", "name", "age"})
+
+	s, err := NewComplementSelector(NewNameSelector("name"), NewIndexSelector(1)).Resolve(h)
+	assert.NoError(t, err)
+	assert.Equal(t, NewComplementSelector(NewIndexSelector(2), NewIndexSelector(1)), s)
+
+	_, err = NewComplementSelector(NewNameSelector("nothing")).Resolve(h)
+	assert.Error(t, err)
+}
@@ -0,0 +1,37 @@
+package column
+
+import (
+	"github.com/xztaityozx/sel/internal/expr"
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/output"
+)
+
+// ExpressionSelector は式を評価した結果を1つのカラムとして書き出すやつ
+type ExpressionSelector struct {
+	expression *expr.Expression
+}
+
+func NewExpressionSelector(src string) (ExpressionSelector, error) {
+	e, err := expr.Compile(src)
+	if err != nil {
+		return ExpressionSelector{}, err
+	}
+	return ExpressionSelector{expression: e}, nil
+}
+
+func (e ExpressionSelector) Select(w *output.Writer, iter iterator.IEnumerable) error {
+	v, err := e.expression.Eval(iter)
+	if err != nil {
+		return err
+	}
+	return w.Write(v)
+}
+
+// Resolve は式の中の $name をヘッダーで解決する
+func (e ExpressionSelector) Resolve(h Header) (Selector, error) {
+	resolved, err := e.expression.Resolve(h.IndexOf)
+	if err != nil {
+		return nil, err
+	}
+	return ExpressionSelector{expression: resolved}, nil
+}
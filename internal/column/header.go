@@ -0,0 +1,171 @@
+package column
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/output"
+)
+
+// Header はヘッダー行のカラム名と index の対応を持つやつ
+type Header struct {
+	names []string
+	index map[string]int
+}
+
+// NewHeader はヘッダー行から Header を作る。同じ名前が複数あるときは先に出てきた方を使う
+func NewHeader(names []string) Header {
+	h := Header{
+		names: make([]string, len(names)),
+		index: make(map[string]int, len(names)),
+	}
+	copy(h.names, names)
+
+	for i, name := range h.names {
+		if _, ok := h.index[name]; !ok {
+			h.index[name] = i + 1
+		}
+	}
+
+	return h
+}
+
+// IndexOf はカラム名に対応する index を返す。1-indexed
+func (h Header) IndexOf(name string) (int, bool) {
+	idx, ok := h.index[name]
+	return idx, ok
+}
+
+// Names はヘッダー行のカラム名を返す
+func (h Header) Names() []string {
+	return h.names
+}
+
+// Resolver はヘッダー行を読むまで選択するカラムが決まらない Selector
+type Resolver interface {
+	Selector
+	// Resolve はヘッダーを使って具体的な Selector を返す
+	Resolve(h Header) (Selector, error)
+}
+
+// Resolve は selectors のうち Resolver なものをヘッダーで解決したスライスを返す。selectors 自体は書き換えない
+func Resolve(selectors []Selector, h Header) ([]Selector, error) {
+	rt := make([]Selector, 0, len(selectors))
+	for _, selector := range selectors {
+		if r, ok := selector.(Resolver); ok {
+			s, err := r.Resolve(h)
+			if err != nil {
+				return nil, err
+			}
+			rt = append(rt, s)
+		} else {
+			rt = append(rt, selector)
+		}
+	}
+	return rt, nil
+}
+
+// NameSelector はヘッダーのカラム名でカラムを選択するやつ。 Resolve すると IndexSelector か RangeSelector になる
+type NameSelector struct {
+	// start, stop はカラム名か数値の index
+	start string
+	stop  string
+	step  int
+	// isRange が false なら start だけを使う
+	isRange bool
+}
+
+// NewNameSelector は単一のカラム名を選択する NameSelector を返す
+func NewNameSelector(name string) NameSelector {
+	return NameSelector{start: name}
+}
+
+// NewNameRangeSelector は start から stop までを選択する NameSelector を返す。
+// start, stop にはカラム名か数値を渡す。start が空なら先頭から、stop が空なら末尾まで
+func NewNameRangeSelector(start, stop string, step int) NameSelector {
+	return NameSelector{start: start, stop: stop, step: step, isRange: true}
+}
+
+// Select は --ltsv のようにカラムにラベルがついていれば、行ごとにラベルで解決して選択する
+// ヘッダーもラベルもなければ何を選べばいいかわからないのでエラーを返す
+func (n NameSelector) Select(w *output.Writer, iter iterator.IEnumerable) error {
+	s, err := n.resolveLabel(iter)
+	if err != nil {
+		return err
+	}
+	return s.Select(w, iter)
+}
+
+func (n NameSelector) Indexes(iter iterator.IEnumerable) ([]int, error) {
+	s, err := n.resolveLabel(iter)
+	if err != nil {
+		return nil, err
+	}
+	return s.(Indexer).Indexes(iter)
+}
+
+// resolveLabel は iter のラベルで解決する。ラベルがない行ではカラムがないときと同じエラーにする
+func (n NameSelector) resolveLabel(iter iterator.IEnumerable) (Selector, error) {
+	l, ok := iter.(iterator.Labeled)
+	if !ok {
+		return nil, fmt.Errorf("%s: column name query requires --header", n.start)
+	}
+	s, err := n.resolve(l)
+	if err != nil {
+		return nil, errors.New(iterator.IndexOutOfRange)
+	}
+	return s, nil
+}
+
+func (n NameSelector) Resolve(h Header) (Selector, error) {
+	return n.resolve(h)
+}
+
+// nameIndex はカラム名から index を引けるもの。Header か iterator.Labeled
+type nameIndex interface {
+	IndexOf(name string) (int, bool)
+}
+
+func (n NameSelector) resolve(h nameIndex) (Selector, error) {
+	if !n.isRange {
+		idx, err := resolveName(h, n.start)
+		if err != nil {
+			return nil, err
+		}
+		return NewIndexSelector(idx), nil
+	}
+
+	start := 1
+	if len(n.start) != 0 {
+		idx, err := resolveName(h, n.start)
+		if err != nil {
+			return nil, err
+		}
+		start = idx
+	}
+
+	if len(n.stop) == 0 {
+		return NewRangeSelector(start, n.step, start, true), nil
+	}
+
+	stop, err := resolveName(h, n.stop)
+	if err != nil {
+		return nil, err
+	}
+	return NewRangeSelector(start, n.step, stop, false), nil
+}
+
+// resolveName は数値ならそのまま index として、そうでないならカラム名として解決する
+func resolveName(h nameIndex, name string) (int, error) {
+	if num, err := strconv.Atoi(name); err == nil {
+		return num, nil
+	}
+
+	idx, ok := h.IndexOf(name)
+	if !ok {
+		return 0, fmt.Errorf("column '%s' is not found in header", name)
+	}
+	return idx, nil
+}
@@ -0,0 +1,82 @@
+package column
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/output"
+)
+
+// HeaderRegexpSelector はヘッダーのカラム名(--ltsv ならラベル)が正規表現にマッチするカラムをすべて選択するやつ
+// SwitchSelector とは違って、マッチを見るのはヘッダー行だけ。以降の行では IndexSelector を並べたのと同じように振る舞う
+type HeaderRegexpSelector struct {
+	pattern  *regexp.Regexp
+	indexes  []IndexSelector
+	resolved bool
+}
+
+func NewHeaderRegexpSelector(pattern string) (HeaderRegexpSelector, error) {
+	r, err := regexp.Compile(pattern)
+	if err != nil {
+		return HeaderRegexpSelector{}, err
+	}
+	return HeaderRegexpSelector{pattern: r}, nil
+}
+
+// Select はヘッダーで解決したカラムを順番に選択する。マッチしたカラムがなければ何も書かない
+// --ltsv のようにカラムにラベルがついていれば、行ごとにラベルがマッチするカラムを選択する
+func (h HeaderRegexpSelector) Select(w *output.Writer, iter iterator.IEnumerable) error {
+	indexes, err := h.selectors(iter)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range indexes {
+		if err := s.Select(w, iter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h HeaderRegexpSelector) Indexes(iter iterator.IEnumerable) ([]int, error) {
+	indexes, err := h.selectors(iter)
+	if err != nil {
+		return nil, err
+	}
+
+	rt := make([]int, 0, len(indexes))
+	for _, s := range indexes {
+		rt = append(rt, s.index)
+	}
+	return rt, nil
+}
+
+// selectors は選択するカラムの IndexSelector を返す。ヘッダーで解決していなければ iter のラベルを使う
+func (h HeaderRegexpSelector) selectors(iter iterator.IEnumerable) ([]IndexSelector, error) {
+	if h.resolved {
+		return h.indexes, nil
+	}
+
+	l, ok := iter.(iterator.Labeled)
+	if !ok {
+		return nil, fmt.Errorf("@/%s/: column name query requires --header", h.pattern)
+	}
+	return h.match(l.Labels()), nil
+}
+
+func (h HeaderRegexpSelector) Resolve(header Header) (Selector, error) {
+	return HeaderRegexpSelector{pattern: h.pattern, indexes: h.match(header.Names()), resolved: true}, nil
+}
+
+// match は名前が正規表現にマッチするカラムの IndexSelector を返す
+func (h HeaderRegexpSelector) match(names []string) []IndexSelector {
+	indexes := make([]IndexSelector, 0, len(names))
+	for i, name := range names {
+		if h.pattern.MatchString(name) {
+			indexes = append(indexes, NewIndexSelector(i+1))
+		}
+	}
+	return indexes
+}
@@ -0,0 +1,78 @@
+package column
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/option"
+	"github.com/xztaityozx/sel/internal/output"
+)
+
+func TestNewHeaderRegexpSelector(t *testing.T) {
+	_, err := NewHeaderRegexpSelector(`^metric_`)
+	assert.NoError(t, err)
+
+	_, err = NewHeaderRegexpSelector(`(`)
+	assert.Error(t, err, "コンパイルできない正規表現はエラーになるべき")
+}
+
+func TestHeaderRegexpSelector_Select(t *testing.T) {
+	h := NewHeader([]string{"id", "metric_a", "name", "metric_b", "metric_c"})
+
+	tests := []struct {
+		name    string
+		pattern string
+		line    string
+		want    string
+	}{
+		{name: "matches some columns", pattern: `^metric_`, line: "1 10 alice 20 30", want: "10 20 30"},
+		{name: "matches nothing", pattern: `^nothing$`, line: "1 10 alice 20 30", want: ""},
+		{name: "matches all columns", pattern: `.`, line: "1 10 alice 20 30", want: "1 10 alice 20 30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewHeaderRegexpSelector(tt.pattern)
+			assert.NoError(t, err)
+
+			resolved, err := s.Resolve(h)
+			assert.NoError(t, err)
+
+			buf := &bytes.Buffer{}
+			w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, buf, false)
+			assert.NoError(t, resolved.Select(w, iterator.NewIterator(tt.line, " ", false)))
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+
+	t.Run("not resolved", func(t *testing.T) {
+		s, _ := NewHeaderRegexpSelector(`^metric_`)
+		w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, &bytes.Buffer{}, false)
+		assert.Error(t, s.Select(w, iterator.NewIterator("a b", " ", false)), "ヘッダーで解決していないならエラーになるべき")
+	})
+}
+
+func TestHeaderRegexpSelector_Select_Labeled(t *testing.T) {
+	s, err := NewHeaderRegexpSelector(`^req`)
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		line string
+		want string
+	}{
+		{line: "host:a\treq:GET\treqtime:0.1", want: "GET 0.1"},
+		{line: "reqtime:0.2\thost:b", want: "0.2"},
+	} {
+		buf := &bytes.Buffer{}
+		w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, buf, false)
+		assert.NoError(t, s.Select(w, iterator.NewLTSVIterator(tt.line)))
+		assert.NoError(t, w.Flush())
+		assert.Equal(t, tt.want, buf.String(), "ラベルは行ごとに見るべき")
+	}
+
+	w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, &bytes.Buffer{}, false)
+	assert.Error(t, s.Select(w, iterator.NewIterator("a b", " ", false)))
+}
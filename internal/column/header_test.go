@@ -0,0 +1,115 @@
+package column
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/option"
+	"github.com/xztaityozx/sel/internal/output"
+)
+
+func TestNewHeader(t *testing.T) {
+	names := []string{"id", "name", "id", "status"}
+	h := NewHeader(names)
+
+	idx, ok := h.IndexOf("id")
+	assert.True(t, ok)
+	assert.Equal(t, 1, idx, "重複したカラム名は先に出てきた方が使われるべき")
+
+	idx, ok = h.IndexOf("status")
+	assert.True(t, ok)
+	assert.Equal(t, 4, idx)
+
+	_, ok = h.IndexOf("nothing")
+	assert.False(t, ok)
+
+	names[0] = "changed"
+	assert.Equal(t, []string{"id", "name", "id", "status"}, h.Names(), "元のスライスを書き換えても影響を受けないべき")
+}
+
+func TestNameSelector_Resolve(t *testing.T) {
+	h := NewHeader([]string{"id", "name", "age", "status"})
+
+	tests := []struct {
+		name     string
+		selector NameSelector
+		want     Selector
+		wantErr  bool
+	}{
+		{name: "name", selector: NewNameSelector("age"), want: NewIndexSelector(3)},
+		{name: "name:name", selector: NewNameRangeSelector("name", "status", 1), want: NewRangeSelector(2, 1, 4, false)},
+		{name: "name:-1", selector: NewNameRangeSelector("name", "-1", 1), want: NewRangeSelector(2, 1, -1, false)},
+		{name: "1:name:2", selector: NewNameRangeSelector("1", "age", 2), want: NewRangeSelector(1, 2, 3, false)},
+		{name: ":name", selector: NewNameRangeSelector("", "name", 1), want: NewRangeSelector(1, 1, 2, false)},
+		{name: "name:", selector: NewNameRangeSelector("age", "", 1), want: NewRangeSelector(3, 1, 3, true)},
+		{name: "not found", selector: NewNameSelector("nothing"), wantErr: true},
+		{name: "not found in range", selector: NewNameRangeSelector("id", "nothing", 1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.selector.Resolve(h)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNameSelector_Select(t *testing.T) {
+	w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, &bytes.Buffer{}, false)
+	err := NewNameSelector("id").Select(w, iterator.NewIterator("a b c", " ", false))
+	assert.Error(t, err, "ヘッダーで解決していないならエラーになるべき")
+}
+
+func TestNameSelector_Select_Labeled(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector NameSelector
+		want     string
+		missing  bool
+	}{
+		{name: "label", selector: NewNameSelector("status"), want: "200"},
+		{name: "label range", selector: NewNameRangeSelector("req", "", 1), want: "GET 200 0.1"},
+		{name: "label to index", selector: NewNameRangeSelector("host", "2", 1), want: "a GET"},
+		{name: "missing label", selector: NewNameSelector("ua"), missing: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, buf, false)
+			iter := iterator.NewLTSVIterator("host:a\treq:GET\tstatus:200\ttime:0.1")
+			err := tt.selector.Select(w, iter)
+			if tt.missing {
+				assert.EqualError(t, err, iterator.IndexOutOfRange)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+
+	idx, err := NewNameSelector("status").Indexes(iterator.NewLTSVIterator("status:1\thost:a"))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, idx)
+}
+
+func TestResolve(t *testing.T) {
+	h := NewHeader([]string{"id", "name"})
+	selectors := []Selector{NewIndexSelector(1), NewNameSelector("name")}
+
+	got, err := Resolve(selectors, h)
+	assert.NoError(t, err)
+	assert.Equal(t, []Selector{NewIndexSelector(1), NewIndexSelector(2)}, got)
+	assert.Equal(t, NewNameSelector("name"), selectors[1], "元のスライスは書き換えないべき")
+
+	_, err = Resolve([]Selector{NewNameSelector("nothing")}, h)
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package column
 
 import (
+	"errors"
 	"github.com/xztaityozx/sel/internal/iterator"
 	"github.com/xztaityozx/sel/internal/output"
 	"strconv"
@@ -35,3 +36,20 @@ func (i IndexSelector) Select(w *output.Writer, iter iterator.IEnumerable) error
 	}
 	return w.Write(item)
 }
+
+func (i IndexSelector) Indexes(iter iterator.IEnumerable) ([]int, error) {
+	if i.index == 0 {
+		return []int{0}, nil
+	}
+
+	m := len(iter.ToArray())
+	idx := i.index
+	if idx < 0 {
+		idx = m + idx + 1
+	}
+
+	if idx < 1 || idx > m {
+		return nil, errors.New(iterator.IndexOutOfRange)
+	}
+	return []int{idx}, nil
+}
@@ -2,6 +2,7 @@ package column
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/xztaityozx/sel/internal/iterator"
 	"github.com/xztaityozx/sel/internal/option"
@@ -78,3 +79,31 @@ func TestIndexSelector_Select(t *testing.T) {
 		}
 	}
 }
+
+func TestIndexSelector_Indexes(t *testing.T) {
+	iter := iterator.NewIterator("a b c", " ", false)
+
+	tests := []struct {
+		index   int
+		want    []int
+		wantErr bool
+	}{
+		{index: 0, want: []int{0}},
+		{index: 2, want: []int{2}},
+		{index: -1, want: []int{3}},
+		{index: 4, wantErr: true},
+		{index: -4, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.index), func(t *testing.T) {
+			got, err := NewIndexSelector(tt.index).Indexes(iter)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
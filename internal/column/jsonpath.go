@@ -0,0 +1,51 @@
+package column
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/jsonl"
+	"github.com/xztaityozx/sel/internal/output"
+)
+
+// JSONPathSelector は .user.id や .items[0].sku のようなパスで JSON の値を選ぶやつ。--jsonl のときに使う
+type JSONPathSelector struct {
+	path jsonl.Path
+}
+
+func NewJSONPathSelector(src string) (JSONPathSelector, error) {
+	p, err := jsonl.ParsePath(src)
+	if err != nil {
+		return JSONPathSelector{}, err
+	}
+	return JSONPathSelector{path: p}, nil
+}
+
+func (j JSONPathSelector) Select(w *output.Writer, iter iterator.IEnumerable) error {
+	s, ok := iter.(jsonl.Documenter)
+	if !ok {
+		return fmt.Errorf("%s: JSON path query requires --jsonl", j.path)
+	}
+
+	doc, err := s.Document()
+	if err != nil {
+		return err
+	}
+
+	values, err := j.path.Select(doc)
+	if err != nil {
+		if errors.Is(err, jsonl.ErrNotFound) {
+			// -M や -E で埋められるように、カラムがないときと同じエラーにする
+			return errors.New(iterator.IndexOutOfRange)
+		}
+		return err
+	}
+
+	for _, v := range values {
+		if err := w.Write(v.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
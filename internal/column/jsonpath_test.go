@@ -0,0 +1,58 @@
+package column
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/jsonl"
+	"github.com/xztaityozx/sel/internal/option"
+	"github.com/xztaityozx/sel/internal/output"
+)
+
+func TestJSONPathSelector_Select(t *testing.T) {
+	line := `{"user":{"id":7},"items":[{"sku":"A1"},{"sku":"B2"}],"tags":["x","y"]}`
+	tests := []struct {
+		path    string
+		want    string
+		missing bool
+	}{
+		{path: ".user.id", want: "7"},
+		{path: ".items[1].sku", want: "B2"},
+		{path: ".items[].sku", want: "A1 B2"},
+		{path: ".tags[]", want: "x y"},
+		{path: ".tags", want: `["x","y"]`},
+		{path: ".user.name", missing: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			s, err := NewJSONPathSelector(tt.path)
+			assert.NoError(t, err)
+
+			buf := &bytes.Buffer{}
+			w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, buf, false)
+			err = s.Select(w, jsonl.NewIterator(line))
+			if tt.missing {
+				assert.EqualError(t, err, iterator.IndexOutOfRange)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestJSONPathSelector_Select_Error(t *testing.T) {
+	s, err := NewJSONPathSelector(".a")
+	assert.NoError(t, err)
+
+	w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, &bytes.Buffer{}, false)
+	assert.Error(t, s.Select(w, iterator.NewIterator(`{"a":1}`, " ", false)))
+	assert.Error(t, s.Select(w, jsonl.NewIterator(`{"a":`)))
+
+	_, err = NewJSONPathSelector(".a[")
+	assert.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package column
+
+import (
+	"regexp"
+
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/output"
+)
+
+// NestedSelector は outer で選択したカラムをさらに別の区切り文字で分割して、inner で選択するやつ
+// 4[,]2 なら4番目のカラムをカンマで分割して2番目を選ぶ
+type NestedSelector struct {
+	outer Selector
+	inner Selector
+	// outer で選択したカラムを分割するイテレーター。カラムごとに Reset して使い回す
+	iter iterator.IEnumerable
+}
+
+// NewNestedSelector は delimiter で分割する NestedSelector を返す。useRegexp なら delimiter を正規表現として扱う
+func NewNestedSelector(outer, inner Selector, delimiter string, useRegexp bool) (NestedSelector, error) {
+	var iter iterator.IEnumerable
+	if useRegexp {
+		r, err := regexp.Compile(delimiter)
+		if err != nil {
+			return NestedSelector{}, err
+		}
+		iter = iterator.NewRegexpIterator("", r, false)
+	} else {
+		iter = iterator.NewIterator("", delimiter, false)
+	}
+
+	return NestedSelector{outer: outer, inner: inner, iter: iter}, nil
+}
+
+func (n NestedSelector) Select(w *output.Writer, iter iterator.IEnumerable) error {
+	columns, err := selectValues(w, n.outer, iter)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range columns {
+		n.iter.Reset(v)
+		if err := n.inner.Select(w, n.iter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolve は outer が Resolver ならヘッダーで解決する。inner はカラムの中身を選ぶものなのでヘッダーとは関係ない
+func (n NestedSelector) Resolve(h Header) (Selector, error) {
+	r, ok := n.outer.(Resolver)
+	if !ok {
+		return n, nil
+	}
+
+	outer, err := r.Resolve(h)
+	if err != nil {
+		return nil, err
+	}
+	return NestedSelector{outer: outer, inner: n.inner, iter: n.iter}, nil
+}
@@ -0,0 +1,72 @@
+package column
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/option"
+	"github.com/xztaityozx/sel/internal/output"
+)
+
+func TestNewNestedSelector(t *testing.T) {
+	_, err := NewNestedSelector(NewIndexSelector(1), NewIndexSelector(1), `\s*,\s*`, true)
+	assert.NoError(t, err)
+
+	_, err = NewNestedSelector(NewIndexSelector(1), NewIndexSelector(1), `(`, true)
+	assert.Error(t, err, "コンパイルできない正規表現はエラーになるべき")
+}
+
+func TestNestedSelector_Select(t *testing.T) {
+	mustNested := func(outer, inner Selector, delimiter string, useRegexp bool) NestedSelector {
+		n, err := NewNestedSelector(outer, inner, delimiter, useRegexp)
+		assert.NoError(t, err)
+		return n
+	}
+
+	line := "id key=a,b,c x:1;y:2 p, q ,r"
+	tests := []struct {
+		name     string
+		selector Selector
+		want     string
+		wantErr  bool
+	}{
+		{name: "2[,]2", selector: mustNested(NewIndexSelector(2), NewIndexSelector(2), ",", false), want: "b"},
+		{name: "2[,]2:3", selector: mustNested(NewIndexSelector(2), NewRangeSelector(2, 1, 3, false), ",", false), want: "b c"},
+		{name: "2[=]2[,]-1", selector: mustNested(NewIndexSelector(2), mustNested(NewIndexSelector(2), NewIndexSelector(-1), ",", false), "=", false), want: "c"},
+		{name: "3:4[;]1", selector: mustNested(NewRangeSelector(3, 1, 4, false), NewIndexSelector(1), ";", false), want: "x:1 p,"},
+		{name: "-1[/\\s*,\\s*/]2", selector: mustNested(NewIndexSelector(-1), NewIndexSelector(2), `\s*,\s*`, true), want: "r"},
+		{name: "0[;]2", selector: mustNested(NewIndexSelector(0), NewIndexSelector(2), ";", false), want: "y:2 p, q ,r"},
+		{name: "out of range", selector: mustNested(NewIndexSelector(2), NewIndexSelector(10), ",", false), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, buf, false)
+			err := tt.selector.Select(w, iterator.NewIterator(line, " ", false))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestNestedSelector_Resolve(t *testing.T) {
+	n, err := NewNestedSelector(NewNameSelector("tags"), NewIndexSelector(2), ",", false)
+	assert.NoError(t, err)
+
+	s, err := n.Resolve(NewHeader([]string{"id", "tags"}))
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, buf, false)
+	assert.NoError(t, s.Select(w, iterator.NewIterator("1 a,b,c", " ", false)))
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "b", buf.String())
+}
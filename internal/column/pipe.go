@@ -0,0 +1,52 @@
+package column
+
+import (
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/output"
+	"github.com/xztaityozx/sel/internal/pipe"
+)
+
+// PipeSelector は inner が書き出す値を pipe.Stage で順番に変換するやつ
+// 3|upper|substr(0,8)
+type PipeSelector struct {
+	inner  Selector
+	stages []pipe.Stage
+}
+
+func NewPipeSelector(inner Selector, stages ...pipe.Stage) PipeSelector {
+	return PipeSelector{inner: inner, stages: stages}
+}
+
+func (p PipeSelector) Select(w *output.Writer, iter iterator.IEnumerable) error {
+	w.Capture()
+	err := p.inner.Select(w, iter)
+	columns := w.Release()
+	if err != nil {
+		return err
+	}
+
+	for i, v := range columns {
+		for _, stage := range p.stages {
+			if v, err = stage.Apply(v); err != nil {
+				return err
+			}
+		}
+		columns[i] = v
+	}
+
+	return w.Write(columns...)
+}
+
+// Resolve は inner が Resolver ならヘッダーで解決する
+func (p PipeSelector) Resolve(h Header) (Selector, error) {
+	r, ok := p.inner.(Resolver)
+	if !ok {
+		return p, nil
+	}
+
+	inner, err := r.Resolve(h)
+	if err != nil {
+		return nil, err
+	}
+	return NewPipeSelector(inner, p.stages...), nil
+}
@@ -0,0 +1,56 @@
+package column
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/option"
+	"github.com/xztaityozx/sel/internal/output"
+	"github.com/xztaityozx/sel/internal/pipe"
+)
+
+func TestPipeSelector_Select(t *testing.T) {
+	upper, _ := pipe.Parse("upper")
+	substr, _ := pipe.Parse("substr(0,2)")
+
+	tests := []struct {
+		name     string
+		selector PipeSelector
+		want     string
+		wantErr  bool
+	}{
+		{name: "2|upper", selector: NewPipeSelector(NewIndexSelector(2), upper), want: "BCD"},
+		{name: "1:3|upper|substr(0,2)", selector: NewPipeSelector(NewRangeSelector(1, 1, 3, false), upper, substr), want: "AB BC CD"},
+		{name: "10|upper", selector: NewPipeSelector(NewIndexSelector(10), upper), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, buf, false)
+			err := tt.selector.Select(w, iterator.NewIterator("abc bcd cde", " ", false))
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, w.Release(), "エラーのときも Capture は解除されるべき")
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestPipeSelector_Resolve(t *testing.T) {
+	upper, _ := pipe.Parse("upper")
+	s, err := NewPipeSelector(NewNameSelector("name"), upper).Resolve(NewHeader([]string{"id", "name"}))
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, buf, false)
+	assert.NoError(t, s.Select(w, iterator.NewIterator("1 alice", " ", false)))
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "ALICE", buf.String())
+}
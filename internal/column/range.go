@@ -1,6 +1,7 @@
 package column
 
 import (
+	"errors"
 	"fmt"
 	"github.com/xztaityozx/sel/internal/iterator"
 	"github.com/xztaityozx/sel/internal/output"
@@ -22,37 +23,58 @@ func (r RangeSelector) Select(w *output.Writer, iter iterator.IEnumerable) error
 	strings := iter.ToArray()
 	m := len(strings)
 
-	start, stop, step := r.normalizeRange(m)
+	start, stop, step, err := r.normalizeRange(m)
+	if err != nil {
+		return err
+	}
 
 	if start == stop {
-		if start > m || start < 1 {
-			return fmt.Errorf("index out of range")
-		}
 		return w.Write(strings[start-1])
 	}
 
 	if start < stop {
-		if step < 0 {
-			return fmt.Errorf("step must be bigger than 0(start:step:stop=%d:%d:%d)", start, step, stop)
-		}
 		return r.selectForward(w, strings, start, stop, step)
 	}
+	return r.selectBackward(w, strings, start, stop, step)
+}
 
-	// start > stop
-	if step > 0 {
-		return fmt.Errorf("step must be less than 0(start:step:stop=%d:%d:%d)", start, step, stop)
+func (r RangeSelector) Indexes(iter iterator.IEnumerable) ([]int, error) {
+	start, stop, step, err := r.normalizeRange(len(iter.ToArray()))
+	if err != nil {
+		return nil, err
 	}
-	return r.selectBackward(w, strings, start, stop, step)
+
+	if start == stop {
+		return []int{start}, nil
+	}
+
+	var rt []int
+	if start < stop {
+		for i := start; i <= stop; i += step {
+			rt = append(rt, i)
+		}
+		return rt, nil
+	}
+	for i := start; i >= stop; i += step {
+		rt = append(rt, i)
+	}
+	return rt, nil
 }
 
-// normalizeRange は範囲パラメータを正規化する
-func (r RangeSelector) normalizeRange(m int) (start, stop, step int) {
-	start = r.start
+// normalizeRange は範囲パラメータを m 個のカラムに対する index に正規化する
+// 負の index が先頭のカラムより前を指すときは 1 に、正の index が末尾のカラムより後ろを指すときは m に寄せる
+// 範囲がまるごと先頭より前か末尾より後ろにあるときは範囲外のエラーにする
+func (r RangeSelector) normalizeRange(m int) (start, stop, step int, err error) {
+	start, step = r.start, r.step
 	if start < 0 {
 		start = m + start + 1
 	}
 
 	stop = r.stop
+	if !r.isInfStop && stop > m && step < 0 {
+		// 後ろ向きの範囲の終わりまで末尾より後ろにある
+		return 0, 0, 0, errors.New(iterator.IndexOutOfRange)
+	}
 	if r.isInfStop || stop >= m {
 		stop = m
 	}
@@ -60,7 +82,34 @@ func (r RangeSelector) normalizeRange(m int) (start, stop, step int) {
 		stop = m + stop + 1
 	}
 
-	return start, stop, r.step
+	if (r.start < 0 && start < 1 && step < 0) || (r.stop < 0 && stop < 1 && step > 0) || (start > m && (step > 0 || m == 0)) {
+		return 0, 0, 0, errors.New(iterator.IndexOutOfRange)
+	}
+	if r.start < 0 {
+		start = max(start, 1)
+	}
+	if start > m {
+		start = m
+	}
+	if r.stop < 0 {
+		stop = max(stop, 1)
+	}
+
+	if start == stop {
+		if start > m || start < 1 {
+			return 0, 0, 0, errors.New(iterator.IndexOutOfRange)
+		}
+		return start, stop, step, nil
+	}
+
+	if start < stop && step < 0 {
+		return 0, 0, 0, fmt.Errorf("step must be bigger than 0(start:step:stop=%d:%d:%d)", start, step, stop)
+	}
+	if start > stop && step > 0 {
+		return 0, 0, 0, fmt.Errorf("step must be less than 0(start:step:stop=%d:%d:%d)", start, step, stop)
+	}
+
+	return start, stop, step, nil
 }
 
 // selectForward は start < stop の場合の選択処理
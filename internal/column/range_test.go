@@ -58,6 +58,9 @@ func TestRangeSelector_Select(t *testing.T) {
 			{start: -1, step: -1, stop: -5, expects: []int{19, 18, 17, 16, 15}},
 			{start: 1, step: 2, stop: 10, expects: []int{0, 2, 4, 6, 8}},
 			{start: -1, step: -2, stop: -10, expects: []int{19, 17, 15, 13, 11}},
+			{start: -30, step: 1, stop: 3, expects: []int{0, 1, 2}},
+			{start: -18, step: -1, stop: -30, expects: []int{2, 1, 0}},
+			{start: 30, step: -1, stop: 18, expects: []int{19, 18, 17}},
 		}
 
 		for _, v := range dataset {
@@ -81,6 +84,8 @@ func TestRangeSelector_Select(t *testing.T) {
 			{start: 0, step: -1, stop: 5},
 			{start: 5, step: 1, stop: 0},
 			{start: 1000, step: 1, stop: 1000},
+			{start: 30, step: 1, stop: 40},
+			{start: -30, step: 1, stop: -25},
 		}
 
 		for _, v := range dataset {
@@ -104,6 +109,50 @@ func TestRangeSelector_Select(t *testing.T) {
 	})
 }
 
+func TestRangeSelector_Indexes(t *testing.T) {
+	iter := &testEnumerable{a: []string{"a", "b", "c", "d", "e"}}
+
+	dataset := []struct {
+		start     int
+		step      int
+		stop      int
+		isInfStop bool
+		expects   []int
+		wantErr   bool
+	}{
+		{start: 1, step: 1, stop: 3, expects: []int{1, 2, 3}},
+		{start: 4, step: -2, stop: 1, expects: []int{4, 2}},
+		{start: -2, step: 1, stop: 1, isInfStop: true, expects: []int{4, 5}},
+		{start: 2, step: 1, stop: 2, expects: []int{2}},
+		{start: 0, step: -1, stop: 5, wantErr: true},
+		{start: 1000, step: 1, stop: 1000, wantErr: true},
+		{start: -10, step: 1, isInfStop: true, expects: []int{1, 2, 3, 4, 5}},
+		{start: -10, step: 1, stop: 2, expects: []int{1, 2}},
+		{start: -10, step: 1, stop: -5, expects: []int{1}},
+		{start: -10, step: 1, stop: -8, wantErr: true},
+		{start: -2, step: -1, stop: -10, expects: []int{4, 3, 2, 1}},
+		{start: -10, step: -1, stop: -20, wantErr: true},
+		{start: 6, step: 1, stop: 10, wantErr: true},
+		{start: 6, step: 1, isInfStop: true, wantErr: true},
+		{start: 4, step: 1, stop: 10, expects: []int{4, 5}},
+		{start: 10, step: -1, stop: 4, expects: []int{5, 4}},
+		{start: 10, step: -1, stop: 8, wantErr: true},
+	}
+
+	for _, v := range dataset {
+		got, err := NewRangeSelector(v.start, v.step, v.stop, v.isInfStop).Indexes(iter)
+		if v.wantErr {
+			assert.Error(t, err, "start: %d, step: %d, stop: %d", v.start, v.step, v.stop)
+			continue
+		}
+		for _, i := range got {
+			assert.True(t, 1 <= i && i <= 5, "index は 1 から len の範囲に収まるべき: %v", got)
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, v.expects, got, "start: %d, step: %d, stop: %d", v.start, v.step, v.stop)
+	}
+}
+
 func BenchmarkRangeSelector_Select_Forward(b *testing.B) {
 	var cols []string
 	for i := 0; i < 100; i++ {
@@ -8,3 +8,21 @@ import (
 type Selector interface {
 	Select(w *output.Writer, iterator iterator.IEnumerable) error
 }
+
+// Indexer は選択するカラムの index を求められる Selector
+type Indexer interface {
+	Selector
+	// Indexes は iter に対して選択するカラムの index を返す。1-indexed で、0 は行全体を表す。負の index は正の index に直して返す
+	Indexes(iter iterator.IEnumerable) ([]int, error)
+}
+
+// selectValues は s が選択する値を書き出さずに返す。0 なら分割する前の行をそのまま返す
+func selectValues(w *output.Writer, s Selector, iter iterator.IEnumerable) ([]string, error) {
+	if i, ok := s.(IndexSelector); ok && i.index == 0 {
+		return []string{iter.Line()}, nil
+	}
+
+	w.Capture()
+	err := s.Select(w, iter)
+	return w.Release(), err
+}
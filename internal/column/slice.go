@@ -0,0 +1,207 @@
+package column
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/output"
+	"github.com/xztaityozx/sel/internal/width"
+)
+
+// SliceUnit は SliceSelector が何を単位にして切り出すか
+type SliceUnit int
+
+const (
+	// SliceByRune は文字(rune)単位で切り出す
+	SliceByRune SliceUnit = iota
+	// SliceByByte はバイト単位で切り出す
+	SliceByByte
+	// SliceByWidth は端末に表示したときの幅を単位にして切り出す
+	SliceByWidth
+)
+
+// SliceSelector は inner で選択したカラムの一部を切り出すやつ。cut -c をカラムの中でやる
+// 3[1:8] なら3番目のカラムの先頭8文字、0[10:20] なら行全体の10文字目から20文字目になる
+type SliceSelector struct {
+	inner     Selector
+	unit      SliceUnit
+	start     int
+	step      int
+	stop      int
+	isInfStop bool
+	// 3[2] のように単一の位置を指定しているかどうか。範囲外ならエラーになる
+	isIndex bool
+}
+
+// NewSliceSelector は start から stop までを step ごとに切り出す SliceSelector を返す。位置は 1-indexed で、負なら末尾から数える
+func NewSliceSelector(inner Selector, unit SliceUnit, start, step, stop int, isInfStop bool) SliceSelector {
+	return SliceSelector{inner: inner, unit: unit, start: start, step: step, stop: stop, isInfStop: isInfStop}
+}
+
+// NewSliceIndexSelector は index の位置だけを切り出す SliceSelector を返す
+func NewSliceIndexSelector(inner Selector, unit SliceUnit, index int) SliceSelector {
+	return SliceSelector{inner: inner, unit: unit, start: index, step: 1, stop: index, isIndex: true}
+}
+
+func (s SliceSelector) Select(w *output.Writer, iter iterator.IEnumerable) error {
+	columns, err := selectValues(w, s.inner, iter)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range columns {
+		sliced, err := s.slice(v)
+		if err != nil {
+			return err
+		}
+		if err := w.Write(sliced); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolve は inner が Resolver ならヘッダーで解決する
+func (s SliceSelector) Resolve(h Header) (Selector, error) {
+	r, ok := s.inner.(Resolver)
+	if !ok {
+		return s, nil
+	}
+
+	inner, err := r.Resolve(h)
+	if err != nil {
+		return nil, err
+	}
+	s.inner = inner
+	return s, nil
+}
+
+func (s SliceSelector) slice(v string) (string, error) {
+	switch s.unit {
+	case SliceByByte:
+		positions, err := s.positions(len(v))
+		if err != nil {
+			return "", err
+		}
+		b := make([]byte, 0, len(positions))
+		for _, p := range positions {
+			b = append(b, v[p-1])
+		}
+		return string(b), nil
+	case SliceByWidth:
+		return s.sliceByWidth(v)
+	}
+
+	rs := []rune(v)
+	positions, err := s.positions(len(rs))
+	if err != nil {
+		return "", err
+	}
+	sliced := make([]rune, 0, len(positions))
+	for _, p := range positions {
+		sliced = append(sliced, rs[p-1])
+	}
+	return string(sliced), nil
+}
+
+// cluster は表示幅で切り出すときの単位。結合文字のような幅0の文字は直前の文字とまとめる
+type cluster struct {
+	s string
+	// 先頭の桁。1-indexed
+	from  int
+	width int
+}
+
+// sliceByWidth は表示幅で切り出す。全角文字のように2桁以上を占める文字は、そのすべての桁が範囲に入っているときだけ切り出す
+func (s SliceSelector) sliceByWidth(v string) (string, error) {
+	var clusters []cluster
+	// owner は桁ごとにその桁を占めている cluster の index
+	var owner []int
+	for i := 0; i < len(v); {
+		r, size := utf8.DecodeRuneInString(v[i:])
+		rw := width.RuneWidth(r)
+
+		n := len(clusters)
+		if n != 0 && (rw == 0 || clusters[n-1].width == 0) {
+			clusters[n-1].s += v[i : i+size]
+			clusters[n-1].width += rw
+		} else {
+			clusters = append(clusters, cluster{s: v[i : i+size], from: len(owner) + 1, width: rw})
+			n++
+		}
+		for range rw {
+			owner = append(owner, n-1)
+		}
+		i += size
+	}
+
+	positions, err := s.positions(len(owner))
+	if err != nil {
+		return "", err
+	}
+
+	selected := make([]bool, len(owner)+1)
+	for _, p := range positions {
+		selected[p] = true
+	}
+
+	emitted := make([]bool, len(clusters))
+	var b strings.Builder
+	for _, p := range positions {
+		c := owner[p-1]
+		if emitted[c] {
+			continue
+		}
+		emitted[c] = true
+
+		covered := true
+		for k := clusters[c].from; k < clusters[c].from+clusters[c].width; k++ {
+			covered = covered && selected[k]
+		}
+		if covered {
+			b.WriteString(clusters[c].s)
+		}
+	}
+	return b.String(), nil
+}
+
+// positions は長さ n の値から切り出す位置を切り出す順に返す。1-indexed
+// 範囲の指定なら RangeSelector と同じように負の位置は末尾から数えて、はみ出した部分は無視する
+func (s SliceSelector) positions(n int) ([]int, error) {
+	if s.isIndex {
+		idx := s.start
+		if idx < 0 {
+			idx = n + idx + 1
+		}
+		if idx < 1 || idx > n {
+			return nil, errors.New(iterator.IndexOutOfRange)
+		}
+		return []int{idx}, nil
+	}
+
+	start := s.start
+	if start < 0 {
+		start = n + start + 1
+	}
+
+	stop := s.stop
+	if s.isInfStop {
+		stop = n
+	} else if stop < 0 {
+		stop = n + stop + 1
+	}
+
+	var rt []int
+	if s.step > 0 {
+		for i := max(start, 1); i <= min(stop, n); i += s.step {
+			rt = append(rt, i)
+		}
+	} else {
+		for i := min(start, n); i >= max(stop, 1); i += s.step {
+			rt = append(rt, i)
+		}
+	}
+	return rt, nil
+}
@@ -0,0 +1,66 @@
+package column
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/iterator"
+	"github.com/xztaityozx/sel/internal/option"
+	"github.com/xztaityozx/sel/internal/output"
+)
+
+func TestSliceSelector_Select(t *testing.T) {
+	line := "id 2024-01-02T03:04:05 こんにちは e\u0301te"
+	tests := []struct {
+		name     string
+		selector Selector
+		want     string
+		wantErr  bool
+	}{
+		{name: "2[1:10]", selector: NewSliceSelector(NewIndexSelector(2), SliceByRune, 1, 1, 10, false), want: "2024-01-02"},
+		{name: "2[12:]", selector: NewSliceSelector(NewIndexSelector(2), SliceByRune, 12, 1, 0, true), want: "03:04:05"},
+		{name: "2[-2:]", selector: NewSliceSelector(NewIndexSelector(2), SliceByRune, -2, 1, 0, true), want: "05"},
+		{name: "2[1:4:2]", selector: NewSliceSelector(NewIndexSelector(2), SliceByRune, 1, 2, 4, false), want: "22"},
+		{name: "1[::-1]", selector: NewSliceSelector(NewIndexSelector(1), SliceByRune, -1, -1, 1, false), want: "di"},
+		{name: "1[1:100]", selector: NewSliceSelector(NewIndexSelector(1), SliceByRune, 1, 1, 100, false), want: "id"},
+		{name: "1[5:10]", selector: NewSliceSelector(NewIndexSelector(1), SliceByRune, 5, 1, 10, false), want: ""},
+		{name: "3[2:3]", selector: NewSliceSelector(NewIndexSelector(3), SliceByRune, 2, 1, 3, false), want: "んに"},
+		{name: "3[b1:3]", selector: NewSliceSelector(NewIndexSelector(3), SliceByByte, 1, 1, 3, false), want: "こ"},
+		{name: "3[w1:5]", selector: NewSliceSelector(NewIndexSelector(3), SliceByWidth, 1, 1, 5, false), want: "こん"},
+		{name: "3[w2:6]", selector: NewSliceSelector(NewIndexSelector(3), SliceByWidth, 2, 1, 6, false), want: "んに"},
+		{name: "3[w-4:]", selector: NewSliceSelector(NewIndexSelector(3), SliceByWidth, -4, 1, 0, true), want: "ちは"},
+		{name: "4[w1:2]", selector: NewSliceSelector(NewIndexSelector(4), SliceByWidth, 1, 1, 2, false), want: "e\u0301t"},
+		{name: "4[2]", selector: NewSliceIndexSelector(NewIndexSelector(4), SliceByRune, 2), want: "\u0301"},
+		{name: "1:2[1]", selector: NewSliceIndexSelector(NewRangeSelector(1, 1, 2, false), SliceByRune, 1), want: "i 2"},
+		{name: "0[4:7]", selector: NewSliceSelector(NewIndexSelector(0), SliceByRune, 4, 1, 7, false), want: "2024"},
+		{name: "1[3]", selector: NewSliceIndexSelector(NewIndexSelector(1), SliceByRune, 3), wantErr: true},
+		{name: "1[-3]", selector: NewSliceIndexSelector(NewIndexSelector(1), SliceByRune, -3), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, buf, false)
+			err := tt.selector.Select(w, iterator.NewIterator(line, " ", false))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestSliceSelector_Resolve(t *testing.T) {
+	s, err := NewSliceSelector(NewNameSelector("date"), SliceByRune, 1, 1, 4, false).Resolve(NewHeader([]string{"id", "date"}))
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := output.NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, buf, false)
+	assert.NoError(t, s.Select(w, iterator.NewIterator("1 2024-01-02", " ", false)))
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "2024", buf.String())
+}
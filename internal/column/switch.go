@@ -116,6 +116,46 @@ func (s SwitchSelector) Select(w *output.Writer, iter iterator.IEnumerable) erro
 	return w.Write(rt...)
 }
 
+func (s SwitchSelector) Indexes(iter iterator.IEnumerable) ([]int, error) {
+	strings := iter.ToArray()
+	maximum := len(strings)
+	minimum := 0
+
+	var rt []int
+	if s.end.isAroundContext {
+		for i, v := range strings {
+			if s.begin.match(v, i) {
+				var from, to int
+				if s.end.num < 0 {
+					from, to = between(i+s.end.num, maximum, minimum), between(i+1, maximum, minimum)
+				} else {
+					from, to = between(i, maximum, minimum), between(i+s.end.num+1, maximum, minimum)
+				}
+				for k := from; k < to; k++ {
+					rt = append(rt, k+1)
+				}
+			}
+		}
+		return rt, nil
+	}
+
+	st := false
+	for i, v := range strings {
+		if st {
+			rt = append(rt, i+1)
+			if s.end.match(v, i) {
+				st = false
+			}
+		} else {
+			st = s.begin.match(v, i)
+			if st {
+				rt = append(rt, i+1)
+			}
+		}
+	}
+	return rt, nil
+}
+
 var numberAddress, _ = regexp.Compile(`^\d+$`)
 var regexpAddress, _ = regexp.Compile(`^/.+/$`)
 var aroundContextAddress, _ = regexp.Compile(`^[+-]\d+$`)
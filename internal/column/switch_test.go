@@ -49,6 +49,10 @@ func (t *testEnumerable) ResetFromArray(_ []string) {
 	panic("implement me")
 }
 
+func (t *testEnumerable) Line() string {
+	panic("implement me")
+}
+
 func (t *testEnumerable) ElementAt(_ int) (string, error) {
 	panic("implement me")
 }
@@ -185,3 +189,30 @@ func TestSwitchSelector_Select(t *testing.T) {
 		})
 	}
 }
+
+func TestSwitchSelector_Indexes(t *testing.T) {
+	iter := &testEnumerable{a: []string{"1", "2", "a", "b", "c", "d", "e", "3", "4"}}
+
+	tests := []struct {
+		begin string
+		end   string
+		want  []int
+	}{
+		{begin: "1", end: "5", want: []int{1, 2, 3, 4, 5}},
+		{begin: "/a/", end: "/e/", want: []int{3, 4, 5, 6, 7}},
+		{begin: "/a/", end: "+5", want: []int{3, 4, 5, 6, 7, 8}},
+		{begin: "/e/", end: "-5", want: []int{2, 3, 4, 5, 6, 7}},
+		{begin: "/x/", end: "/e/", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.begin+":"+tt.end, func(t *testing.T) {
+			s, err := NewSwitchSelector(tt.begin, tt.end)
+			assert.NoError(t, err)
+
+			got, err := s.Indexes(iter)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	// bzip2 のヘッダーの後には、ブロックか終端のマジックナンバーが続く
+	bzip2BlockMagic = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+	bzip2EndMagic   = []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90}
+)
+
+// headerSize はどの形式かを判別するために読む先頭のバイト数
+const headerSize = 10
+
+// trialSize は zlib のヘッダーに見えたときに、試しに展開してみる先頭のバイト数
+const trialSize = 4096
+
+// NewReader は r の先頭のマジックナンバーを見て、gzip, bzip2, zlib で圧縮されていれば展開しながら読む io.Reader を返す
+// どれでもなければそのまま読む
+func NewReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, trialSize)
+	// 読み込みのエラーは、この後 br を読んだときに返ってくるのでここでは見ない
+	head, _ := br.Peek(headerSize)
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		// 複数の gzip をつなげたファイルもまとめて読む
+		return gzip.NewReader(br)
+	case isBzip2(head):
+		return bzip2.NewReader(br), nil
+	case isZlibHeader(head):
+		// ヘッダーはテキストでも偶然そろうことがあるので、先頭を試しに展開できたときだけ zlib として読む
+		// 読めるだけ読んで確かめるのは、ヘッダーがそろったときだけにする。ふつうのテキストの入力が待たされないように
+		if trial, _ := br.Peek(trialSize); inflates(trial) {
+			return zlib.NewReader(br)
+		}
+	}
+	return br, nil
+}
+
+// isBzip2 は BZh1 から BZh9 のあとにブロックか終端のマジックナンバーが続いているかどうかを返す
+func isBzip2(head []byte) bool {
+	if len(head) < headerSize || !bytes.HasPrefix(head, bzip2Magic) || head[3] < '1' || '9' < head[3] {
+		return false
+	}
+	return bytes.Equal(head[4:], bzip2BlockMagic) || bytes.Equal(head[4:], bzip2EndMagic)
+}
+
+// isZlibHeader は RFC 1950 の zlib のヘッダーかどうかを返す
+// 圧縮方式が deflate(CM=8) でウィンドウが 32KiB 以下、FCHECK が合っていて、辞書を使わないものだけを見る
+func isZlibHeader(head []byte) bool {
+	if len(head) < 2 {
+		return false
+	}
+	cmf, flg := head[0], head[1]
+	if cmf&0x0f != 8 || cmf>>4 > 7 || flg&0x20 != 0 {
+		return false
+	}
+	return (uint16(cmf)<<8|uint16(flg))%31 == 0
+}
+
+// inflates は head を zlib として展開できるかどうかを返す
+// 入力が head より長いときは、head の終わりで途切れていても壊れていなければ展開できるものとする
+func inflates(head []byte) bool {
+	zr, err := zlib.NewReader(bytes.NewReader(head))
+	if err != nil {
+		return false
+	}
+	_, err = io.Copy(io.Discard, zr)
+	return err == nil || (errors.Is(err, io.ErrUnexpectedEOF) && len(head) == trialSize)
+}
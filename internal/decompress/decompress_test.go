@@ -0,0 +1,93 @@
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	_, err := w.Write([]byte(s))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zlibbed(t *testing.T, s string, level int) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w, err := zlib.NewWriterLevel(buf, level)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte(s))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// longText は圧縮しても trialSize より長くなるように、繰り返しの少ない行を並べたもの
+func longText() string {
+	var b strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&b, "%d %x\n", i, i*i*7919)
+	}
+	return b.String()
+}
+
+// bzip2 の圧縮は標準ライブラリにないので、"a b\nc d\n" を圧縮したものを使う
+var bzipped = []byte("\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\xd2\x59\x8d\x98\x00\x00\x02\x51\x00\x00\x10\x40\x00\x3c\x00\x20\x00\x30\xc0\x08\x69\xb2\x88\x23\x27\x8b\xb9\x22\x9c\x28\x48\x69\x2c\xc6\xcc\x00")
+
+func TestNewReader(t *testing.T) {
+	long := longText()
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{name: "plain", input: []byte("a b\nc d\n"), want: "a b\nc d\n"},
+		{name: "short", input: []byte("a"), want: "a"},
+		{name: "empty", input: nil, want: ""},
+		{name: "gzip", input: gzipped(t, "a b\nc d\n"), want: "a b\nc d\n"},
+		{name: "concatenated gzip", input: append(gzipped(t, "a b\n"), gzipped(t, "c d\n")...), want: "a b\nc d\n"},
+		{name: "bzip2", input: bzipped, want: "a b\nc d\n"},
+		{name: "zlib", input: zlibbed(t, "a b\nc d\n", zlib.DefaultCompression), want: "a b\nc d\n"},
+		{name: "zlib best speed", input: zlibbed(t, "a b\nc d\n", zlib.BestSpeed), want: "a b\nc d\n"},
+		{name: "zlib level 3", input: zlibbed(t, "a b\nc d\n", 3), want: "a b\nc d\n"},
+		{name: "zlib longer than trial", input: zlibbed(t, long, zlib.BestSpeed), want: long},
+		{name: "text like zlib", input: []byte("x^2 + y^2\n"), want: "x^2 + y^2\n"},
+		// Shift_JIS で "x" のあとに "愛" (0x88 0xA4) や "ﾚ" (0xDA) が続くテキスト
+		{name: "shift_jis like zlib", input: []byte("x\x9c\x88\xa4\n"), want: "x\x9c\x88\xa4\n"},
+		{name: "shift_jis like zlib best compression", input: []byte("x\xda\xda\xda abc\n"), want: "x\xda\xda\xda abc\n"},
+		{name: "zlib header only", input: []byte{0x78, 0x9c}, want: "\x78\x9c"},
+		{name: "text like bzip2", input: []byte("BZh9 is a header\n"), want: "BZh9 is a header\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(tt.input))
+			assert.NoError(t, err)
+			got, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestLongText(t *testing.T) {
+	assert.Greater(t, len(zlibbed(t, longText(), zlib.BestSpeed)), trialSize, "試しに展開するより長い入力でテストするべき")
+}
+
+func TestNewReader_Broken(t *testing.T) {
+	// gzip のマジックナンバーだけあってヘッダーが壊れている
+	_, err := NewReader(bytes.NewReader([]byte{0x1f, 0x8b, 0x00}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package expr
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// builtin は式の中で使える関数
+type builtin struct {
+	minArgs int
+	// -1 なら可変長
+	maxArgs int
+	f       func(args []value) (value, error)
+}
+
+var builtins = map[string]builtin{
+	"len": {1, 1, func(args []value) (value, error) {
+		return numberValue(float64(utf8.RuneCountInString(args[0].String()))), nil
+	}},
+	"upper": {1, 1, func(args []value) (value, error) {
+		return stringValue(strings.ToUpper(args[0].String())), nil
+	}},
+	"lower": {1, 1, func(args []value) (value, error) {
+		return stringValue(strings.ToLower(args[0].String())), nil
+	}},
+	"trim": {1, 1, func(args []value) (value, error) {
+		return stringValue(strings.TrimSpace(args[0].String())), nil
+	}},
+	"contains": {2, 2, func(args []value) (value, error) {
+		return boolValue(strings.Contains(args[0].String(), args[1].String())), nil
+	}},
+	"replace": {3, 3, func(args []value) (value, error) {
+		return stringValue(strings.ReplaceAll(args[0].String(), args[1].String(), args[2].String())), nil
+	}},
+	"substr": {2, 3, substr},
+	"int":    {1, 1, numeric(math.Trunc)},
+	"floor":  {1, 1, numeric(math.Floor)},
+	"ceil":   {1, 1, numeric(math.Ceil)},
+	"abs":    {1, 1, numeric(math.Abs)},
+	"round": {1, 2, func(args []value) (value, error) {
+		n, err := args[0].Number()
+		if err != nil {
+			return value{}, err
+		}
+		digits := 0.0
+		if len(args) == 2 {
+			if digits, err = args[1].Number(); err != nil {
+				return value{}, err
+			}
+		}
+		p := math.Pow(10, math.Trunc(digits))
+		return numberValue(math.Round(n*p) / p), nil
+	}},
+	"min": {1, -1, fold(math.Min)},
+	"max": {1, -1, fold(math.Max)},
+	// if は使わない方の引数を評価しないように call.eval で特別扱いしている
+	"if": {3, 3, nil},
+}
+
+// numeric は数値1つを受け取る関数を builtin 用にする
+func numeric(f func(float64) float64) func(args []value) (value, error) {
+	return func(args []value) (value, error) {
+		n, err := args[0].Number()
+		if err != nil {
+			return value{}, err
+		}
+		return numberValue(f(n)), nil
+	}
+}
+
+// fold は2つの数値を受け取る関数で可変長の引数を畳み込む
+func fold(f func(a, b float64) float64) func(args []value) (value, error) {
+	return func(args []value) (value, error) {
+		acc, err := args[0].Number()
+		if err != nil {
+			return value{}, err
+		}
+		for _, a := range args[1:] {
+			n, err := a.Number()
+			if err != nil {
+				return value{}, err
+			}
+			acc = f(acc, n)
+		}
+		return numberValue(acc), nil
+	}
+}
+
+// substr は0から数えた start 番目の rune から length 個を取り出す。start が負なら末尾から数える
+func substr(args []value) (value, error) {
+	s := []rune(args[0].String())
+	start, err := args[1].Number()
+	if err != nil {
+		return value{}, err
+	}
+
+	from := int(start)
+	if from < 0 {
+		from += len(s)
+	}
+	from = min(max(from, 0), len(s))
+
+	to := len(s)
+	if len(args) == 3 {
+		length, err := args[2].Number()
+		if err != nil {
+			return value{}, err
+		}
+		to = min(max(from+int(length), from), len(s))
+	}
+
+	return stringValue(string(s[from:to])), nil
+}
@@ -0,0 +1,87 @@
+package expr
+
+import (
+	"fmt"
+
+	"github.com/xztaityozx/sel/internal/iterator"
+)
+
+// Expression はコンパイル済みの式。計算カラムと --where の条件に使う
+//
+//	$3 * 1000
+//	$2 . "-" . $4
+//	len($5)
+//	$3 =~ /ERROR/ && $5 > 100
+type Expression struct {
+	src   string
+	root  node
+	names map[string]int
+	// 式の中で使われているカラム名
+	unresolved []string
+}
+
+// Compile は式をパースして Expression を返す
+func Compile(src string) (*Expression, error) {
+	return compile(src, false)
+}
+
+// CompileCondition は --where の条件として式をパースして Expression を返す
+// Compile と違って、3 =~ /ERROR/ や status == "ok" のように比較の左辺の裸の整数や識別子はカラムの参照になる
+func CompileCondition(src string) (*Expression, error) {
+	return compile(src, true)
+}
+
+func compile(src string, bareColumns bool) (*Expression, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{src: src, tokens: tokens, bareColumns: bareColumns}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected '%s'", t.value)
+	}
+
+	return &Expression{src: src, root: root, unresolved: p.names}, nil
+}
+
+// Eval は iter を現在の行として式を評価して、結果を文字列で返す
+func (e *Expression) Eval(iter iterator.IEnumerable) (string, error) {
+	v, err := e.root.eval(context{iter: iter, names: e.names})
+	if err != nil {
+		return "", err
+	}
+	return v.String(), nil
+}
+
+// Match は iter を現在の行として式を評価して、結果を真偽値で返す
+// fillMissing が nil でないときは範囲外のカラムを fillMissing として扱う
+func (e *Expression) Match(iter iterator.IEnumerable, fillMissing *string) (bool, error) {
+	v, err := e.root.eval(context{iter: iter, names: e.names, fillMissing: fillMissing})
+	if err != nil {
+		return false, err
+	}
+	return v.Bool(), nil
+}
+
+// Resolve は lookup を使って式の中のカラム名を index に解決した Expression を返す
+func (e *Expression) Resolve(lookup func(name string) (int, bool)) (*Expression, error) {
+	names := make(map[string]int, len(e.unresolved))
+	for _, name := range e.unresolved {
+		idx, ok := lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("column '%s' is not found in header", name)
+		}
+		names[name] = idx
+	}
+
+	return &Expression{src: e.src, root: e.root, names: names, unresolved: e.unresolved}, nil
+}
+
+func (e *Expression) String() string {
+	return e.src
+}
@@ -0,0 +1,166 @@
+package expr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/iterator"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr bool
+	}{
+		{name: "arithmetic", src: `$3 * 1000`},
+		{name: "concat", src: `$2 . "-" . $4`},
+		{name: "function", src: `len($5)`},
+		{name: "name", src: `${user id} . $status`},
+		{name: "nested", src: `if($1 > 10, upper($2), lower($2))`},
+		{name: "unterminated string", src: `"abc`, wantErr: true},
+		{name: "unterminated paren", src: `($1 + 1`, wantErr: true},
+		{name: "unknown function", src: `nothing($1)`, wantErr: true},
+		{name: "wrong number of arguments", src: `len($1, $2)`, wantErr: true},
+		{name: "$0", src: `$0`, wantErr: true},
+		{name: "$ only", src: `$ + 1`, wantErr: true},
+		{name: "trailing token", src: `$1 $2`, wantErr: true},
+		{name: "empty", src: ``, wantErr: true},
+		{name: "unknown character", src: `$1 # 2`, wantErr: true},
+		{name: "regexp", src: `$1 =~ /^a.*\/b$/ || $2 !~ "x"`},
+		{name: "regexp without =~", src: `/a/`, wantErr: true},
+		{name: "invalid regexp", src: `$1 =~ /(/`, wantErr: true},
+		{name: "column after =~", src: `$1 =~ $2`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.src)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestExpression_Eval(t *testing.T) {
+	line := "alice 20 1.5 tokyo  hello world"
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{src: `$2 * 1000`, want: "20000"},
+		{src: `$3 * 2`, want: "3"},
+		{src: `$2 / 8`, want: "2.5"},
+		{src: `$2 % 7`, want: "6"},
+		{src: `-$2 + 1`, want: "-19"},
+		{src: `1 + 2 * 3`, want: "7"},
+		{src: `(1 + 2) * 3`, want: "9"},
+		{src: `$1 . "-" . $4`, want: "alice-tokyo"},
+		{src: `$1 . (1 + 1)`, want: "alice2"},
+		{src: `len($1)`, want: "5"},
+		{src: `len($5)`, want: "0"},
+		{src: `upper($1)`, want: "ALICE"},
+		{src: `lower("ABC")`, want: "abc"},
+		{src: `trim("  a ")`, want: "a"},
+		{src: `replace($4, "o", "0")`, want: "t0ky0"},
+		{src: `substr($1, 1, 3)`, want: "lic"},
+		{src: `substr($1, -2)`, want: "ce"},
+		{src: `int($3)`, want: "1"},
+		{src: `round(2.345, 2)`, want: "2.35"},
+		{src: `floor(-1.5) . ceil(1.2) . abs(-3)`, want: "-223"},
+		{src: `min($2, 3, 10)`, want: "3"},
+		{src: `max($2, 3, 10)`, want: "20"},
+		{src: `$2 > 3`, want: "true"},
+		{src: `"20" == 20.0`, want: "true"},
+		{src: `$1 < "bob"`, want: "true"},
+		{src: `$2 >= 20 && $1 != "bob"`, want: "true"},
+		{src: `!($2 < 10 || false)`, want: "true"},
+		{src: `if($2 > 10, "big", "small")`, want: "big"},
+		{src: `if(false, $100, "lazy")`, want: "lazy"},
+		{src: `contains($-1, "or")`, want: "true"},
+		{src: `$1 =~ /^al/`, want: "true"},
+		{src: `$4 !~ /^to/`, want: "false"},
+		{src: `$2 / 4 =~ /^5$/`, want: "true"},
+		{src: `'it\'s' . "\t"`, want: "it's\t"},
+	}
+
+	iter := iterator.NewIterator(line, " ", false)
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			e, err := Compile(tt.src)
+			assert.NoError(t, err)
+
+			iter.Reset(line)
+			got, err := e.Eval(iter)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpression_Eval_Error(t *testing.T) {
+	iter := iterator.NewIterator("alice 20", " ", false)
+
+	tests := []struct {
+		src         string
+		notANumber  bool
+		outOfRange  bool
+		description string
+	}{
+		{src: `$1 * 2`, notANumber: true, description: "数値でない値の算術演算は ErrNotNumber になるべき"},
+		{src: `abs($1)`, notANumber: true, description: "数値を受け取る関数も ErrNotNumber になるべき"},
+		{src: `$10`, outOfRange: true, description: "範囲外のカラムは IndexOutOfRange になるべき"},
+		{src: `$2 / 0`, description: "0除算はエラーになるべき"},
+		{src: `$name`, description: "ヘッダーで解決していないカラム名はエラーになるべき"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			e, err := Compile(tt.src)
+			assert.NoError(t, err)
+
+			iter.Reset("alice 20")
+			_, err = e.Eval(iter)
+			assert.Error(t, err, tt.description)
+			assert.Equal(t, tt.notANumber, errors.Is(err, ErrNotNumber), tt.description)
+			assert.Equal(t, tt.outOfRange, err.Error() == iterator.IndexOutOfRange, tt.description)
+		})
+	}
+}
+
+func TestExpression_Eval_Labeled(t *testing.T) {
+	e, err := Compile(`$host . ":" . $status`)
+	assert.NoError(t, err)
+
+	got, err := e.Eval(iterator.NewLTSVIterator("status:200\thost:a"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a:200", got)
+
+	_, err = e.Eval(iterator.NewLTSVIterator("host:a"))
+	assert.EqualError(t, err, iterator.IndexOutOfRange)
+}
+
+func TestExpression_Resolve(t *testing.T) {
+	e, err := Compile(`$name . ":" . ${the age}`)
+	assert.NoError(t, err)
+
+	header := map[string]int{"name": 1, "the age": 2}
+	lookup := func(name string) (int, bool) {
+		idx, ok := header[name]
+		return idx, ok
+	}
+
+	resolved, err := e.Resolve(lookup)
+	assert.NoError(t, err)
+
+	got, err := resolved.Eval(iterator.NewIterator("alice 20", " ", false))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice:20", got)
+
+	_, err = e.Resolve(func(string) (int, bool) { return 0, false })
+	assert.Error(t, err)
+}
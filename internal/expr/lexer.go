@@ -0,0 +1,212 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	// =~ と !~ の右辺に書く /regexp/
+	tokenRegexp
+	// $3, $-1, $name, ${name with space}
+	tokenColumn
+	// 関数名か true/false
+	tokenIdent
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// operators は前方一致で探すので、"<" より "<=" のように長いものを先に並べておく
+var operators = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "+", "-", "*", "/", "%", ".", "<", ">", "!"}
+
+func isIdentRune(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// tokenize は式をトークンに分割する
+func tokenize(src string) ([]token, error) {
+	var rt []token
+	s := []rune(src)
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			rt = append(rt, token{kind: tokenLeftParen, value: "(", pos: i})
+			i++
+		case c == ')':
+			rt = append(rt, token{kind: tokenRightParen, value: ")", pos: i})
+			i++
+		case c == ',':
+			rt = append(rt, token{kind: tokenComma, value: ",", pos: i})
+			i++
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(s) && unicode.IsDigit(s[i]) {
+				i++
+			}
+			if i+1 < len(s) && s[i] == '.' && unicode.IsDigit(s[i+1]) {
+				i++
+				for i < len(s) && unicode.IsDigit(s[i]) {
+					i++
+				}
+			}
+			rt = append(rt, token{kind: tokenNumber, value: string(s[start:i]), pos: start})
+		case c == '"' || c == '\'':
+			v, n, err := readString(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at %d in '%s'", err, i, src)
+			}
+			rt = append(rt, token{kind: tokenString, value: v, pos: i})
+			i += n
+		case c == '/' && followsMatchOperator(rt):
+			v, n, err := readRegexp(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at %d in '%s'", err, i, src)
+			}
+			rt = append(rt, token{kind: tokenRegexp, value: v, pos: i})
+			i += n
+		case c == '$':
+			v, n, err := readColumn(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at %d in '%s'", err, i, src)
+			}
+			rt = append(rt, token{kind: tokenColumn, value: v, pos: i})
+			i += n
+		case isIdentRune(c):
+			start := i
+			for i < len(s) && isIdentRune(s[i]) {
+				i++
+			}
+			rt = append(rt, token{kind: tokenIdent, value: string(s[start:i]), pos: start})
+		default:
+			op, ok := findOperator(s[i:])
+			if !ok {
+				return nil, fmt.Errorf("unexpected '%c' at %d in '%s'", c, i, src)
+			}
+			rt = append(rt, token{kind: tokenOperator, value: op, pos: i})
+			i += len(op)
+		}
+	}
+
+	return append(rt, token{kind: tokenEOF, pos: len(s)}), nil
+}
+
+func findOperator(s []rune) (string, bool) {
+	for _, op := range operators {
+		if strings.HasPrefix(string(s), op) {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// followsMatchOperator は直前のトークンが =~ か !~ かどうか。そのときだけ / を割り算ではなく正規表現の始まりとして読む
+func followsMatchOperator(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == tokenOperator && (last.value == "=~" || last.value == "!~")
+}
+
+// readRegexp は /regexp/ を読む。\/ だけを / にして、それ以外のエスケープは正規表現にそのまま渡す
+// 戻り値は中身と、閉じる / までを含めて読んだ rune の数
+func readRegexp(s []rune) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '/':
+			b.WriteRune('/')
+			i++
+		case s[i] == '/':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteRune(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated /")
+}
+
+// readString はクォートされた文字列を読む。\n, \t, \\ と閉じるクォートのエスケープに対応する
+// 戻り値は中身と、閉じるクォートまでを含めて読んだ rune の数
+func readString(s []rune) (string, int, error) {
+	q := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] == q {
+			return b.String(), i + 1, nil
+		}
+
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteRune('\n')
+			case 't':
+				b.WriteRune('\t')
+			default:
+				b.WriteRune(s[i])
+			}
+			continue
+		}
+
+		b.WriteRune(s[i])
+	}
+	return "", 0, fmt.Errorf("unterminated %c", q)
+}
+
+// readColumn は $3, $-1, $name, ${name} を読んで $ を除いた部分を返す
+func readColumn(s []rune) (string, int, error) {
+	if len(s) < 2 {
+		return "", 0, fmt.Errorf("column is expected after $")
+	}
+
+	if s[1] == '{' {
+		for i := 2; i < len(s); i++ {
+			if s[i] == '}' {
+				if i == 2 {
+					return "", 0, fmt.Errorf("empty column name")
+				}
+				return string(s[2:i]), i + 1, nil
+			}
+		}
+		return "", 0, fmt.Errorf("unterminated ${")
+	}
+
+	i := 1
+	if s[i] == '-' {
+		i++
+		for i < len(s) && unicode.IsDigit(s[i]) {
+			i++
+		}
+		if i == 2 {
+			return "", 0, fmt.Errorf("index is expected after $-")
+		}
+		return string(s[1:i]), i, nil
+	}
+
+	for i < len(s) && isIdentRune(s[i]) {
+		i++
+	}
+	if i == 1 {
+		return "", 0, fmt.Errorf("column is expected after $")
+	}
+	return string(s[1:i]), i, nil
+}
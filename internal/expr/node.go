@@ -0,0 +1,254 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xztaityozx/sel/internal/iterator"
+)
+
+// context は1行分の評価に必要なもの
+type context struct {
+	iter iterator.IEnumerable
+	// ヘッダーで解決したカラム名と index の対応
+	names map[string]int
+	// nil でないときは範囲外のカラムをこの値として扱う
+	fillMissing *string
+}
+
+type node interface {
+	eval(ctx context) (value, error)
+}
+
+type literal struct {
+	v value
+}
+
+func (l literal) eval(_ context) (value, error) {
+	return l.v, nil
+}
+
+// columnRef は $3 や $name のようなカラムの参照
+type columnRef struct {
+	index int
+	name  string
+}
+
+func newColumnRef(s string) columnRef {
+	if idx, err := strconv.Atoi(s); err == nil {
+		return columnRef{index: idx}
+	}
+	return columnRef{name: s}
+}
+
+func (c columnRef) eval(ctx context) (value, error) {
+	idx := c.index
+	if len(c.name) != 0 {
+		var ok bool
+		idx, ok = ctx.names[c.name]
+		if !ok {
+			// --ltsv のようにカラムにラベルがついていれば、行ごとにラベルで引く
+			l, labeled := ctx.iter.(iterator.Labeled)
+			if !labeled {
+				return value{}, fmt.Errorf("$%s: column name in expression requires --header", c.name)
+			}
+			if idx, ok = l.IndexOf(c.name); !ok {
+				return ctx.missing()
+			}
+		}
+	}
+
+	s, err := ctx.iter.ElementAt(idx)
+	if err != nil {
+		if err.Error() == iterator.IndexOutOfRange {
+			return ctx.missing()
+		}
+		return value{}, err
+	}
+	return stringValue(s), nil
+}
+
+// missing は範囲外のカラムの値。fillMissing がなければ iterator.IndexOutOfRange のエラーにする
+func (ctx context) missing() (value, error) {
+	if ctx.fillMissing != nil {
+		return stringValue(*ctx.fillMissing), nil
+	}
+	return value{}, errors.New(iterator.IndexOutOfRange)
+}
+
+type unary struct {
+	operator string
+	operand  node
+}
+
+func (u unary) eval(ctx context) (value, error) {
+	v, err := u.operand.eval(ctx)
+	if err != nil {
+		return value{}, err
+	}
+
+	if u.operator == "!" {
+		return boolValue(!v.Bool()), nil
+	}
+
+	n, err := v.Number()
+	if err != nil {
+		return value{}, err
+	}
+	return numberValue(-n), nil
+}
+
+type binary struct {
+	operator    string
+	left, right node
+}
+
+func (b binary) eval(ctx context) (value, error) {
+	left, err := b.left.eval(ctx)
+	if err != nil {
+		return value{}, err
+	}
+
+	// && と || は短絡評価する
+	switch b.operator {
+	case "&&":
+		if !left.Bool() {
+			return boolValue(false), nil
+		}
+		right, err := b.right.eval(ctx)
+		return boolValue(right.Bool()), err
+	case "||":
+		if left.Bool() {
+			return boolValue(true), nil
+		}
+		right, err := b.right.eval(ctx)
+		return boolValue(right.Bool()), err
+	}
+
+	right, err := b.right.eval(ctx)
+	if err != nil {
+		return value{}, err
+	}
+
+	switch b.operator {
+	case ".":
+		return stringValue(left.String() + right.String()), nil
+	case "==", "!=", "<", "<=", ">", ">=":
+		return compare(b.operator, left, right), nil
+	}
+
+	l, err := left.Number()
+	if err != nil {
+		return value{}, err
+	}
+	r, err := right.Number()
+	if err != nil {
+		return value{}, err
+	}
+
+	switch b.operator {
+	case "+":
+		return numberValue(l + r), nil
+	case "-":
+		return numberValue(l - r), nil
+	case "*":
+		return numberValue(l * r), nil
+	case "/":
+		if r == 0 {
+			return value{}, errors.New("division by zero")
+		}
+		return numberValue(l / r), nil
+	case "%":
+		if r == 0 {
+			return value{}, errors.New("division by zero")
+		}
+		return numberValue(math.Mod(l, r)), nil
+	}
+
+	return value{}, fmt.Errorf("unknown operator: %s", b.operator)
+}
+
+// compare は両辺が数値として読めるなら数値で、そうでないなら文字列で比較する
+func compare(operator string, left, right value) value {
+	var cmp int
+	if left.isNumeric() && right.isNumeric() {
+		l, _ := left.Number()
+		r, _ := right.Number()
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(left.String(), right.String())
+	}
+
+	switch operator {
+	case "==":
+		return boolValue(cmp == 0)
+	case "!=":
+		return boolValue(cmp != 0)
+	case "<":
+		return boolValue(cmp < 0)
+	case "<=":
+		return boolValue(cmp <= 0)
+	case ">":
+		return boolValue(cmp > 0)
+	}
+	return boolValue(cmp >= 0)
+}
+
+// match は左辺の文字列が正規表現にマッチするかどうか。negate なら !~
+type match struct {
+	operand node
+	pattern *regexp.Regexp
+	negate  bool
+}
+
+func (m match) eval(ctx context) (value, error) {
+	v, err := m.operand.eval(ctx)
+	if err != nil {
+		return value{}, err
+	}
+	return boolValue(m.pattern.MatchString(v.String()) != m.negate), nil
+}
+
+type call struct {
+	name string
+	fn   builtin
+	args []node
+}
+
+func (c call) eval(ctx context) (value, error) {
+	// if だけは使わない方を評価しないようにする
+	if c.name == "if" {
+		cond, err := c.args[0].eval(ctx)
+		if err != nil {
+			return value{}, err
+		}
+		if cond.Bool() {
+			return c.args[1].eval(ctx)
+		}
+		return c.args[2].eval(ctx)
+	}
+
+	args := make([]value, 0, len(c.args))
+	for _, a := range c.args {
+		v, err := a.eval(ctx)
+		if err != nil {
+			return value{}, err
+		}
+		args = append(args, v)
+	}
+
+	v, err := c.fn.f(args)
+	if err != nil {
+		return value{}, fmt.Errorf("%s(): %w", c.name, err)
+	}
+	return v, nil
+}
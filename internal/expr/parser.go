@@ -0,0 +1,271 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// parser は式を node にする。下に行くほど優先順位が高い
+//
+//	or         ::= and ( "||" and )*
+//	and        ::= comparison ( "&&" comparison )*
+//	comparison ::= operand ( ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) additive | ( "=~" | "!~" ) ( regexp | string ) )?
+//	operand    ::= additive | bare
+//	bare       ::= "-"? integer | ident   (bareColumns のときだけ。左辺のカラムの参照になる)
+//	additive   ::= term ( ( "+" | "-" | "." ) term )*
+//	term       ::= unary ( ( "*" | "/" | "%" ) unary )*
+//	unary      ::= ( "-" | "!" ) unary | primary
+//	primary    ::= number | string | column | true | false | ident "(" args ")" | "(" or ")"
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+	// 式の中で使われているカラム名
+	names []string
+	// bareColumns のときは、比較の左辺にある裸の整数や識別子を $3 や $name と同じカラムの参照として読む
+	bareColumns bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// acceptOperator は次のトークンが ops のどれかならそれを読んで返す
+func (p *parser) acceptOperator(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.value == op {
+			p.next()
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) errorf(t token, format string, a ...any) error {
+	return fmt.Errorf("%s at %d in '%s'", fmt.Sprintf(format, a...), t.pos, p.src)
+}
+
+// binaryLevel は左結合な2項演算子の1段分を読む
+func (p *parser) binaryLevel(operand func() (node, error), ops ...string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.acceptOperator(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{operator: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.binaryLevel(p.parseAnd, "||")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.binaryLevel(p.parseComparison, "&&")
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, ok, err := p.parseBareColumn()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if left, err = p.parseAdditive(); err != nil {
+			return nil, err
+		}
+	}
+
+	if op, ok := p.acceptOperator("=~", "!~"); ok {
+		return p.parseMatch(left, op)
+	}
+
+	op, ok := p.acceptOperator("==", "!=", "<", "<=", ">", ">=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	return binary{operator: op, left: left, right: right}, nil
+}
+
+// comparisonOperators は比較の演算子。裸のカラムの参照はこれの左辺にだけ書ける
+var comparisonOperators = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "=~": true, "!~": true}
+
+// parseBareColumn は bareColumns のときに、比較の左辺にある 3 や -1 や status をカラムの参照として読む
+// 裸のカラムの参照でなければ何も読まずに false を返す
+func (p *parser) parseBareColumn() (node, bool, error) {
+	if !p.bareColumns {
+		return nil, false, nil
+	}
+
+	i := p.pos
+	negative := p.tokens[i].kind == tokenOperator && p.tokens[i].value == "-"
+	if negative {
+		i++
+	}
+
+	t := p.tokens[i]
+	switch {
+	case t.kind == tokenNumber:
+		if _, err := strconv.Atoi(t.value); err != nil {
+			return nil, false, nil
+		}
+	case t.kind == tokenIdent && !negative && t.value != "true" && t.value != "false":
+	default:
+		return nil, false, nil
+	}
+	if op := p.tokens[i+1]; op.kind != tokenOperator || !comparisonOperators[op.value] {
+		return nil, false, nil
+	}
+	p.pos = i + 1
+
+	name := t.value
+	if negative {
+		name = "-" + name
+	}
+	c := newColumnRef(name)
+	if len(c.name) != 0 {
+		p.names = append(p.names, c.name)
+	} else if c.index == 0 {
+		return nil, false, p.errorf(t, "index 0 cannot be used in --where")
+	}
+	return c, true, nil
+}
+
+// parseMatch は =~ と !~ の右辺を読んで、正規表現をコンパイルしておく
+func (p *parser) parseMatch(left node, op string) (node, error) {
+	t := p.next()
+	if t.kind != tokenRegexp && t.kind != tokenString {
+		return nil, p.errorf(t, "/regexp/ is expected after %s", op)
+	}
+	r, err := regexp.Compile(t.value)
+	if err != nil {
+		return nil, p.errorf(t, "%s", err)
+	}
+	return match{operand: left, pattern: r, negate: op == "!~"}, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	return p.binaryLevel(p.parseTerm, "+", "-", ".")
+}
+
+func (p *parser) parseTerm() (node, error) {
+	return p.binaryLevel(p.parseUnary, "*", "/", "%")
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if op, ok := p.acceptOperator("-", "!"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unary{operator: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		n, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, p.errorf(t, "%s", err)
+		}
+		return literal{v: numberValue(n)}, nil
+	case tokenString:
+		return literal{v: stringValue(t.value)}, nil
+	case tokenColumn:
+		c := newColumnRef(t.value)
+		if len(c.name) != 0 {
+			p.names = append(p.names, c.name)
+		} else if c.index == 0 {
+			return nil, p.errorf(t, "$0 cannot be used in expression")
+		}
+		return c, nil
+	case tokenLeftParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenRightParen {
+			return nil, p.errorf(t, "')' is expected")
+		}
+		return inner, nil
+	case tokenIdent:
+		switch t.value {
+		case "true":
+			return literal{v: boolValue(true)}, nil
+		case "false":
+			return literal{v: boolValue(false)}, nil
+		}
+		return p.parseCall(t)
+	}
+
+	if t.kind == tokenEOF {
+		return nil, p.errorf(t, "unexpected end of expression")
+	}
+	return nil, p.errorf(t, "unexpected '%s'", t.value)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := builtins[name.value]
+	if !ok {
+		return nil, p.errorf(name, "unknown function '%s'", name.value)
+	}
+
+	if t := p.next(); t.kind != tokenLeftParen {
+		return nil, p.errorf(t, "'(' is expected after %s", name.value)
+	}
+
+	var args []node
+	if p.peek().kind == tokenRightParen {
+		p.next()
+	} else {
+		for {
+			a, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, a)
+
+			t := p.next()
+			if t.kind == tokenRightParen {
+				break
+			}
+			if t.kind != tokenComma {
+				return nil, p.errorf(t, "',' or ')' is expected")
+			}
+		}
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, p.errorf(name, "wrong number of arguments for %s: %d", name.value, len(args))
+	}
+
+	return call{name: name.value, fn: fn, args: args}, nil
+}
@@ -0,0 +1,91 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrNotNumber は数値として扱えない値を算術演算に使おうとしたときのエラー
+// -M/-E が指定されているときは範囲外のカラムと同じように埋める値に置き換えられる
+var ErrNotNumber = errors.New("not a number")
+
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindNumber
+	kindBool
+)
+
+// value は式を評価した結果
+type value struct {
+	kind valueKind
+	str  string
+	num  float64
+	b    bool
+}
+
+func stringValue(s string) value {
+	return value{kind: kindString, str: s}
+}
+
+func numberValue(n float64) value {
+	return value{kind: kindNumber, num: n}
+}
+
+func boolValue(b bool) value {
+	return value{kind: kindBool, b: b}
+}
+
+// String は値を出力用の文字列にする。整数になる数値は小数点をつけない
+func (v value) String() string {
+	switch v.kind {
+	case kindNumber:
+		if v.num == math.Trunc(v.num) && math.Abs(v.num) < 1e15 {
+			return strconv.FormatInt(int64(v.num), 10)
+		}
+		return strconv.FormatFloat(v.num, 'f', -1, 64)
+	case kindBool:
+		return strconv.FormatBool(v.b)
+	}
+	return v.str
+}
+
+// Number は値を数値として返す。文字列は前後の空白を取り除いてから数値として読む
+func (v value) Number() (float64, error) {
+	switch v.kind {
+	case kindNumber:
+		return v.num, nil
+	case kindBool:
+		if v.b {
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(v.str), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: '%s'", ErrNotNumber, v.str)
+	}
+	return n, nil
+}
+
+// Bool は値を真偽値として返す。空文字列, "0", "false", 0 は false
+func (v value) Bool() bool {
+	switch v.kind {
+	case kindNumber:
+		return v.num != 0
+	case kindBool:
+		return v.b
+	}
+	return v.str != "" && v.str != "0" && v.str != "false"
+}
+
+// isNumeric は数値として扱えるかどうか。比較を数値でやるか文字列でやるかを決めるのに使う
+func (v value) isNumeric() bool {
+	_, err := v.Number()
+	return err == nil
+}
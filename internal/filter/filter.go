@@ -0,0 +1,62 @@
+package filter
+
+import (
+	"github.com/xztaityozx/sel/internal/column"
+	"github.com/xztaityozx/sel/internal/expr"
+	"github.com/xztaityozx/sel/internal/iterator"
+)
+
+// Filter は --where で指定されたすべての条件を満たす行だけを通すやつ
+// 条件は =( ) の計算カラムと同じ式で書くが、比較の左辺の 3 や status のような裸の整数や識別子はカラムの参照になる
+type Filter struct {
+	conditions []*expr.Expression
+	// --invert-where が指定されているときは grep -v のように条件を満たさない行を通す
+	invert bool
+}
+
+// NewFilter は --where の式をコンパイルして Filter を返す。複数の式は AND で結合される
+func NewFilter(exprs []string, invert bool) (Filter, error) {
+	conditions := make([]*expr.Expression, 0, len(exprs))
+	for _, src := range exprs {
+		e, err := expr.CompileCondition(src)
+		if err != nil {
+			return Filter{}, err
+		}
+		conditions = append(conditions, e)
+	}
+
+	return Filter{conditions: conditions, invert: invert}, nil
+}
+
+// Match は iter が条件を満たすかどうかを返す。条件がひとつもないときはすべての行を通す
+// 条件が参照するカラムが行にないときは、fillMissing がなければその条件を満たさないものとして扱う
+func (f Filter) Match(iter iterator.IEnumerable, fillMissing *string) (bool, error) {
+	if len(f.conditions) == 0 {
+		return true, nil
+	}
+
+	for _, c := range f.conditions {
+		ok, err := c.Match(iter, fillMissing)
+		if err != nil && err.Error() != iterator.IndexOutOfRange {
+			return false, err
+		}
+		if !ok {
+			return f.invert, nil
+		}
+	}
+	return !f.invert, nil
+}
+
+// Resolve は条件に含まれるカラム名をヘッダーで解決した Filter を返す
+func (f Filter) Resolve(h column.Header) (Filter, error) {
+	conditions := make([]*expr.Expression, 0, len(f.conditions))
+	for _, c := range f.conditions {
+		r, err := c.Resolve(h.IndexOf)
+		if err != nil {
+			return Filter{}, err
+		}
+		conditions = append(conditions, r)
+	}
+
+	return Filter{conditions: conditions, invert: f.invert}, nil
+}
@@ -0,0 +1,160 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/column"
+	"github.com/xztaityozx/sel/internal/iterator"
+)
+
+func TestNewFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		exprs   []string
+		wantErr bool
+	}{
+		{name: "regexp", exprs: []string{`$3 =~ /ERROR/`}},
+		{name: "numeric", exprs: []string{`$5 > 100`}},
+		{name: "and", exprs: []string{`$status == "ok" && $-1 != ""`}},
+		{name: "grouping", exprs: []string{`!($1 == "a" || $2 == "b") && $3 !~ /x/`}},
+		{name: "computed", exprs: []string{`len($1) * 2 > $2`}},
+		{name: "multiple", exprs: []string{`$1 == "a"`, `$2 == "b"`}},
+		{name: "bare columns", exprs: []string{`3 =~ /ERROR/`, `5 > 100`, `status == "ok" && -1 != ""`}},
+		{name: "bare index 0", exprs: []string{`0 == "a"`}, wantErr: true},
+		{name: "bare name on right side", exprs: []string{`$1 == status`}, wantErr: true},
+		{name: "unterminated string", exprs: []string{`$1 == "a`}, wantErr: true},
+		{name: "unterminated regexp", exprs: []string{`$1 =~ /a`}, wantErr: true},
+		{name: "invalid regexp", exprs: []string{`$1 =~ /(/`}, wantErr: true},
+		{name: "regexp with ==", exprs: []string{`$1 == /a/`}, wantErr: true},
+		{name: "missing regexp", exprs: []string{`$1 =~ $2`}, wantErr: true},
+		{name: "missing value", exprs: []string{`$1 ==`}, wantErr: true},
+		{name: "missing paren", exprs: []string{`($1 == "a"`}, wantErr: true},
+		{name: "trailing token", exprs: []string{`$1 == "a" "b"`}, wantErr: true},
+		{name: "index 0", exprs: []string{`$0 == "a"`}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewFilter(tt.exprs, false)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	tests := []struct {
+		name   string
+		exprs  []string
+		invert bool
+		line   string
+		want   bool
+	}{
+		{name: "no conditions", exprs: nil, line: "a b c", want: true},
+		{name: "=~ matches", exprs: []string{`$3 =~ /ERROR/`}, line: "2024 app ERROR: failed", want: true},
+		{name: "=~ does not match", exprs: []string{`$3 =~ /ERROR/`}, line: "2024 app INFO: ok", want: false},
+		{name: "!~", exprs: []string{`$3 !~ /ERROR/`}, line: "2024 app INFO: ok", want: true},
+		{name: "=~ with string", exprs: []string{`$1 =~ "^a/b$"`}, line: "a/b", want: true},
+		{name: "escaped slash", exprs: []string{`$1 =~ /^a\/b$/`}, line: "a/b", want: true},
+		{name: "numeric >", exprs: []string{`$2 > 100`}, line: "a 200", want: true},
+		{name: "numeric > is not string compare", exprs: []string{`$2 > 100`}, line: "a 99", want: false},
+		{name: "numeric ==", exprs: []string{`$2 == 1`}, line: "a 1.0", want: true},
+		{name: "string <", exprs: []string{`$1 < "b"`}, line: "a 1", want: true},
+		{name: "<=, >=", exprs: []string{`$1 <= 10 && $1 >= 10`}, line: "10", want: true},
+		{name: "computed", exprs: []string{`$1 * $2 > 100`}, line: "20 6", want: true},
+		{name: "negative index", exprs: []string{`$-1 != ""`}, line: "a b", want: true},
+		{name: "empty string", exprs: []string{`$-1 == ""`}, line: "a ", want: true},
+		{name: "single quote", exprs: []string{`$1 == 'a b'`}, line: "a b", want: false},
+		{name: "or", exprs: []string{`$1 == "x" || $2 == "b"`}, line: "a b", want: true},
+		{name: "not", exprs: []string{`!($1 == "a")`}, line: "a b", want: false},
+		{name: "multiple are AND-ed", exprs: []string{`$1 == "a"`, `$2 == "c"`}, line: "a b", want: false},
+		{name: "bare index", exprs: []string{`3 =~ /ERROR/`}, line: "a b ERROR", want: true},
+		{name: "bare index compares with number", exprs: []string{`2 > 100`}, line: "a 200", want: true},
+		{name: "bare negative index", exprs: []string{`-1 != ""`}, line: "a b", want: true},
+		{name: "bare number in arithmetic is literal", exprs: []string{`2 + 1 == 3`}, line: "a b", want: true},
+		{name: "invert", exprs: []string{`$1 == "a"`}, invert: true, line: "a b", want: false},
+		{name: "invert not matched", exprs: []string{`$1 == "x"`}, invert: true, line: "a b", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(tt.exprs, tt.invert)
+			assert.NoError(t, err)
+
+			got, err := f.Match(iterator.NewIterator(tt.line, " ", false), nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFilter_Match_Missing(t *testing.T) {
+	f, err := NewFilter([]string{`$5 == ""`}, false)
+	assert.NoError(t, err)
+
+	got, err := f.Match(iterator.NewIterator("a b", " ", false), nil)
+	assert.NoError(t, err, "範囲外のカラムはエラーにならないべき")
+	assert.False(t, got, "範囲外のカラムを参照する条件は満たさないべき")
+
+	inverted, err := NewFilter([]string{`$5 == ""`}, true)
+	assert.NoError(t, err)
+	got, err = inverted.Match(iterator.NewIterator("a b", " ", false), nil)
+	assert.NoError(t, err)
+	assert.True(t, got, "--invert-where では満たさない行として通すべき")
+
+	fill := ""
+	got, err = f.Match(iterator.NewIterator("a b", " ", false), &fill)
+	assert.NoError(t, err)
+	assert.True(t, got, "fillMissing があるときはその値として扱うべき")
+}
+
+func TestFilter_Match_Labeled(t *testing.T) {
+	f, err := NewFilter([]string{`$status >= 400`}, false)
+	assert.NoError(t, err)
+
+	got, err := f.Match(iterator.NewLTSVIterator("host:a\tstatus:404"), nil)
+	assert.NoError(t, err)
+	assert.True(t, got)
+
+	got, err = f.Match(iterator.NewLTSVIterator("status:200\thost:b"), nil)
+	assert.NoError(t, err)
+	assert.False(t, got)
+
+	got, err = f.Match(iterator.NewLTSVIterator("host:c"), nil)
+	assert.NoError(t, err)
+	assert.False(t, got, "ラベルがない行は条件を満たさないべき")
+}
+
+func TestFilter_Resolve(t *testing.T) {
+	f, err := NewFilter([]string{`$status == "ok" && !($name =~ /^b/)`}, false)
+	assert.NoError(t, err)
+
+	_, err = f.Match(iterator.NewIterator("1 alice ok", " ", false), nil)
+	assert.Error(t, err, "ヘッダーで解決していないならエラーになるべき")
+
+	resolved, err := f.Resolve(column.NewHeader([]string{"id", "name", "status"}))
+	assert.NoError(t, err)
+
+	got, err := resolved.Match(iterator.NewIterator("1 alice ok", " ", false), nil)
+	assert.NoError(t, err)
+	assert.True(t, got)
+
+	got, err = resolved.Match(iterator.NewIterator("2 bob ok", " ", false), nil)
+	assert.NoError(t, err)
+	assert.False(t, got)
+
+	_, err = f.Resolve(column.NewHeader([]string{"id"}))
+	assert.Error(t, err)
+
+	bare, err := NewFilter([]string{`status == "ok"`}, false)
+	assert.NoError(t, err)
+	resolved, err = bare.Resolve(column.NewHeader([]string{"id", "name", "status"}))
+	assert.NoError(t, err)
+	got, err = resolved.Match(iterator.NewIterator("1 alice ok", " ", false), nil)
+	assert.NoError(t, err)
+	assert.True(t, got, "裸の識別子もヘッダーのカラム名として解決するべき")
+}
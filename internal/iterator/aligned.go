@@ -0,0 +1,101 @@
+package iterator
+
+import "github.com/xztaityozx/sel/internal/width"
+
+// NewAlignedIterator は ps や docker ps の出力のように、空白で桁揃えされた行を分割する FixedWidthIterator を返す
+// カラムの境界は header の単語の位置から決めて、samples があればそれを見て調整する。分割したカラムの前後の空白は取り除く
+func NewAlignedIterator(header string, samples []string, displayWidth bool, re bool) *FixedWidthIterator {
+	f := NewFixedWidthIterator("", InferBoundaries(header, samples, displayWidth), -1, displayWidth, re)
+	f.trimSpace = true
+	return f
+}
+
+// InferBoundaries は桁揃えされた header と samples から、各カラムの開始位置を推測して返す。位置は 0-indexed
+//
+// header の単語の間に2つ以上の空白があればそこをカラムの境界にする。空白が1つだけのときは CONTAINER ID のような
+// 空白を含むカラム名とみなしてつなげるが、samples のすべての行でその位置が空白で、かつ後ろの単語の下に値があるなら
+// TIME CMD のような別のカラムとして分ける
+// samples があるときは、右寄せされたカラムの値がカラム名より左にはみ出していても分割できるように、
+// すべての行が空白になっている位置まで境界を左にずらす
+func InferBoundaries(header string, samples []string, displayWidth bool) []int {
+	h := occupancy(header, displayWidth)
+	rows := make([][]bool, 0, len(samples))
+	for _, s := range samples {
+		rows = append(rows, occupancy(s, displayWidth))
+	}
+
+	// blank はすべての samples で p が空白かどうか
+	blank := func(p int) bool {
+		for _, r := range rows {
+			if p < len(r) && r[p] {
+				return false
+			}
+		}
+		return true
+	}
+
+	// blankRange は [from, to) がすべての samples で空白かどうか
+	blankRange := func(from, to int) bool {
+		for p := from; p < to; p++ {
+			if !blank(p) {
+				return false
+			}
+		}
+		return true
+	}
+
+	starts := []int{0}
+	prevEnd := -1
+	for p := 0; p < len(h); {
+		if !h[p] {
+			p++
+			continue
+		}
+
+		// [p, e) が header の単語
+		e := p
+		for e < len(h) && h[e] {
+			e++
+		}
+
+		if prevEnd >= 0 {
+			gap := p - prevEnd
+			switch {
+			case gap >= 2 && len(rows) == 0:
+				starts = append(starts, p)
+			case gap >= 2:
+				c := p
+				for c > prevEnd+1 && !blank(c-1) {
+					c--
+				}
+				if !blank(c - 1) {
+					c = p
+				}
+				starts = append(starts, c)
+			case len(rows) != 0 && blank(prevEnd) && !blankRange(p, e):
+				starts = append(starts, p)
+			}
+		}
+
+		prevEnd = e
+		p = e
+	}
+	return starts
+}
+
+// occupancy は s の各位置に空白以外の文字があるかどうかを返す。位置は rune か表示幅で数える
+func occupancy(s string, displayWidth bool) []bool {
+	var rt []bool
+	for _, r := range s {
+		w := 1
+		if displayWidth {
+			if w = width.RuneWidth(r); w == 0 {
+				continue
+			}
+		}
+		for range w {
+			rt = append(rt, r != ' ' && r != '\t')
+		}
+	}
+	return rt
+}
@@ -0,0 +1,59 @@
+package iterator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferBoundaries(t *testing.T) {
+	dockerPs := []string{
+		"CONTAINER ID   IMAGE     COMMAND   CREATED      STATUS       NAMES",
+		"4c01db0b339c   nginx     \"nginx\"   2 days ago   Up 3 hours   web",
+		"d7886598dbe2   redis     \"redis\"   3 days ago   Up 2 days    cache",
+	}
+	ps := []string{
+		"  PID TTY          TIME CMD",
+		"    1 pts/0    00:00:00 bash",
+		"12345 pts/0    00:00:01 sel",
+	}
+	df := []string{
+		"Filesystem  1K-blocks    Used  Available Use% Mounted on",
+		"/dev/sda1   102400000 5120000   97280000   5% /",
+		"tmpfs      1024000000       0 1024000000   0% /dev/shm",
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		samples []string
+		want    []int
+	}{
+		{name: "docker ps header only", header: dockerPs[0], want: []int{0, 15, 25, 35, 48, 61}},
+		{name: "docker ps with samples", header: dockerPs[0], samples: dockerPs[1:], want: []int{0, 15, 25, 35, 48, 61}},
+		{name: "ps header only", header: ps[0], want: []int{0, 19}},
+		{name: "ps with samples", header: ps[0], samples: ps[1:], want: []int{0, 6, 15, 24}},
+		{name: "df header only", header: df[0], want: []int{0, 12, 25, 31}},
+		{name: "df with samples", header: df[0], samples: df[1:], want: []int{0, 11, 22, 30, 41, 46}},
+		{name: "empty", header: "", want: []int{0}},
+		{name: "display width", header: "名前    年齢", samples: []string{"太郎    20"}, want: []int{0, 8}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, InferBoundaries(tt.header, tt.samples, true))
+		})
+	}
+}
+
+func TestNewAlignedIterator(t *testing.T) {
+	header := "CONTAINER ID   IMAGE     STATUS       NAMES"
+	row := "4c01db0b339c   nginx     Up 3 hours   web"
+
+	a := NewAlignedIterator(header, []string{row}, false, false)
+	a.Reset(header)
+	assert.Equal(t, []string{"CONTAINER ID", "IMAGE", "STATUS", "NAMES"}, a.ToArray())
+	a.Reset(row)
+	assert.Equal(t, []string{"4c01db0b339c", "nginx", "Up 3 hours", "web"}, a.ToArray())
+	assert.Equal(t, row, a.Line())
+}
@@ -0,0 +1,83 @@
+package iterator
+
+import (
+	"strings"
+
+	"github.com/xztaityozx/sel/internal/width"
+)
+
+// FixedWidthIterator は決まった位置で分割するイテレーター。固定長のレコードを読むのに使う
+// 分割したあとは PreSplitIterator と同じ
+type FixedWidthIterator struct {
+	*PreSplitIterator
+	// 各カラムの開始位置。0-indexed
+	starts []int
+	// 最後のカラムの終了位置。負なら行末まで
+	end int
+	// 位置を rune ではなく表示幅で数えるかどうか
+	displayWidth bool
+	// 分割したカラムの前後の空白を取り除くかどうか
+	trimSpace bool
+}
+
+// NewFixedWidthIterator は starts の位置で分割する FixedWidthIterator を返す
+// 行が短くても starts と同じ数のカラムに分割する。足りない部分は空文字列になる
+func NewFixedWidthIterator(s string, starts []int, end int, displayWidth bool, re bool) *FixedWidthIterator {
+	f := &FixedWidthIterator{
+		PreSplitIterator: &PreSplitIterator{removeEmpty: re},
+		starts:           starts,
+		end:              end,
+		displayWidth:     displayWidth,
+	}
+	f.Reset(s)
+	return f
+}
+
+func (f *FixedWidthIterator) Reset(s string) {
+	f.ResetFromArray(f.split(s))
+	f.line, f.hasLine = s, true
+}
+
+// split は s を starts の位置で分割する
+func (f *FixedWidthIterator) split(s string) []string {
+	bounds := f.starts
+	if f.end >= 0 {
+		bounds = append(bounds[:len(bounds):len(bounds)], f.end)
+	}
+
+	// offsets は各位置が s の何バイト目にあたるか
+	offsets := make([]int, len(bounds))
+	for i := range offsets {
+		offsets[i] = len(s)
+	}
+
+	k, pos := 0, 0
+	for i, r := range s {
+		w := 1
+		if f.displayWidth {
+			// 結合文字のような幅0の文字は直前の文字と同じカラムに入れる
+			if w = width.RuneWidth(r); w == 0 {
+				continue
+			}
+		}
+
+		for k < len(bounds) && bounds[k] <= pos {
+			offsets[k] = i
+			k++
+		}
+		pos += w
+	}
+
+	a := make([]string, len(f.starts))
+	for i := range a {
+		to := len(s)
+		if i+1 < len(offsets) {
+			to = offsets[i+1]
+		}
+		a[i] = s[offsets[i]:to]
+		if f.trimSpace {
+			a[i] = strings.TrimSpace(a[i])
+		}
+	}
+	return a
+}
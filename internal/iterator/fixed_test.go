@@ -0,0 +1,48 @@
+package iterator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedWidthIterator_Reset(t *testing.T) {
+	tests := []struct {
+		name         string
+		starts       []int
+		end          int
+		displayWidth bool
+		s            string
+		want         []string
+	}{
+		{name: "widths 3,4,-", starts: []int{0, 3, 7}, end: -1, s: "abcdefghijk", want: []string{"abc", "defg", "hijk"}},
+		{name: "widths 3,4", starts: []int{0, 3}, end: 7, s: "abcdefghijk", want: []string{"abc", "defg"}},
+		{name: "cuts 3,6", starts: []int{2, 5}, end: -1, s: "abcdefghijk", want: []string{"cde", "fghijk"}},
+		{name: "short line", starts: []int{0, 3, 7}, end: 10, s: "abcd", want: []string{"abc", "d", ""}},
+		{name: "empty line", starts: []int{0, 3}, end: -1, s: "", want: []string{"", ""}},
+		{name: "runes", starts: []int{0, 2, 4}, end: -1, s: "日本語です", want: []string{"日本", "語で", "す"}},
+		{name: "display width", starts: []int{0, 4, 6}, end: -1, displayWidth: true, s: "日本語ab", want: []string{"日本", "語", "ab"}},
+		{name: "display width with narrow", starts: []int{0, 3}, end: -1, displayWidth: true, s: "a日本", want: []string{"a日", "本"}},
+		{name: "combining", starts: []int{0, 1}, end: -1, displayWidth: true, s: "e\u0301x", want: []string{"e\u0301", "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFixedWidthIterator("", tt.starts, tt.end, tt.displayWidth, false)
+			f.Reset(tt.s)
+			assert.Equal(t, tt.want, f.ToArray())
+			assert.Equal(t, tt.s, f.Line())
+
+			if len(tt.want) != 0 {
+				last, err := f.ElementAt(-1)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want[len(tt.want)-1], last)
+			}
+		})
+	}
+}
+
+func TestFixedWidthIterator_RemoveEmpty(t *testing.T) {
+	f := NewFixedWidthIterator("ab", []int{0, 2, 4}, -1, false, true)
+	assert.Equal(t, []string{"ab"}, f.ToArray())
+}
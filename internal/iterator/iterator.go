@@ -16,6 +16,8 @@ type IEnumerable interface {
 	ToArray() []string
 	Reset(s string)
 	ResetFromArray(a []string)
+	// Line は分割する前の文字列を返す
+	Line() string
 }
 
 // NewIEnumerable は option.Option から適切な IEnumerable を生成して返す
@@ -26,8 +28,41 @@ func NewIEnumerable(option option.Option) (IEnumerable, error) {
 		return NewPreSplitIterator("", string(comma), option.RemoveEmpty), nil
 	}
 
-	if option.UseRegexp {
-		r, err := regexp.Compile(option.InputDelimiter)
+	if option.Ltsv {
+		return NewLTSVIterator(""), nil
+	}
+
+	if option.Logfmt {
+		return NewLogfmtIterator(""), nil
+	}
+
+	if option.Quoted {
+		// クォートの中かどうかは前から読まないとわからないので、-g や -S とは組み合わせない
+		return NewQuotedIterator("", option.QuoteChars, !option.NoEscape), nil
+	}
+
+	if option.Aligned {
+		// カラムの位置はヘッダーを読むまでわからないので、それまでは行全体を1つのカラムにしておく
+		return NewAlignedIterator("", nil, option.DisplayWidth, option.RemoveEmpty), nil
+	}
+
+	if option.IsFixedWidth() {
+		// 固定長のときは区切り文字ではなく位置で分割する
+		starts, end := option.Boundaries()
+		return NewFixedWidthIterator("", starts, end, option.DisplayWidth, option.RemoveEmpty), nil
+	}
+
+	if option.UseRegexp || option.Paragraph {
+		pattern := option.InputDelimiter
+		if !option.UseRegexp {
+			pattern = regexp.QuoteMeta(pattern)
+		}
+		if option.Paragraph {
+			// 段落モードでは awk の RS="" と同じように、区切り文字に加えて改行でもカラムを区切る
+			pattern = `\n|(?:` + pattern + `)`
+		}
+
+		r, err := regexp.Compile(pattern)
 		if err != nil {
 			return nil, err
 		}
@@ -78,6 +113,8 @@ type Iterator struct {
 	front []string
 	// 後方から分割した結果 (index 0 = 最後の要素 = -1)
 	back []string
+	// オリジナルの文字列
+	s string
 	// 未分割の残り文字列
 	remaining string
 	// 区切り文字
@@ -98,6 +135,7 @@ func (i *Iterator) String() string {
 
 // Reset はこのイテレーターをリセットする
 func (i *Iterator) Reset(s string) {
+	i.s = s
 	i.remaining = s
 	i.front = resetStringSlice(i.front)
 	i.back = resetStringSlice(i.back)
@@ -256,12 +294,17 @@ func (i *Iterator) ResetFromArray(_ []string) {
 	panic("not impl")
 }
 
+func (i *Iterator) Line() string {
+	return i.s
+}
+
 func NewIterator(s, sep string, removeEmpty bool) *Iterator {
 	// 初期容量を設定（平均的なカラム数を想定）
 	const initialCap = 16
 	return &Iterator{
 		front:       make([]string, 0, initialCap),
 		back:        make([]string, 0, initialCap),
+		s:           s,
 		remaining:   s,
 		sep:         sep,
 		sepLen:      len(sep),
@@ -275,8 +318,10 @@ type RegexpIterator struct {
 	r *strings.Reader
 	// 区切りとなる正規表現
 	sep *regexp.Regexp
-	// オリジナルの文字列
+	// 未分割の残り文字列
 	s string
+	// オリジナルの文字列
+	line string
 	// 前方から分割した結果 (index 0 = 1番目の要素)
 	front []string
 	// 後方から分割した結果 (index 0 = 最後の要素 = -1)
@@ -423,6 +468,7 @@ func (r *RegexpIterator) ToArray() []string {
 
 func (r *RegexpIterator) Reset(s string) {
 	r.s = s
+	r.line = s
 	r.r.Reset(s)
 	r.front = resetStringSlice(r.front)
 	r.back = resetStringSlice(r.back)
@@ -433,12 +479,17 @@ func (r *RegexpIterator) ResetFromArray(_ []string) {
 	panic("not impl")
 }
 
+func (r *RegexpIterator) Line() string {
+	return r.line
+}
+
 func NewRegexpIterator(s string, sep *regexp.Regexp, re bool) *RegexpIterator {
 	const initialCap = 16
 	return &RegexpIterator{
 		r:           strings.NewReader(s),
 		sep:         sep,
 		s:           s,
+		line:        s,
 		front:       make([]string, 0, initialCap),
 		back:        make([]string, 0, initialCap),
 		removeEmpty: re,
@@ -76,6 +76,7 @@ func TestIterator_Reset(t *testing.T) {
 			as.Equal(tt.fields.sepLen, i.sepLen)
 			as.Equal(tt.fields.removeEmpty, i.removeEmpty)
 			as.Equal(tt.args.s, i.remaining)
+			as.Equal(tt.args.s, i.Line())
 			as.Equal(0, len(i.front))
 			as.Equal(0, len(i.back))
 			as.Nil(i.a)
@@ -313,6 +314,9 @@ func TestRegexpIterator_Reset(t *testing.T) {
 			assert.Equal(t, 0, len(r.back))
 			assert.Equal(t, tt.args.s, r.s)
 			assert.Nil(t, r.a)
+
+			_, _ = r.Next()
+			assert.Equal(t, tt.args.s, r.Line(), "分割した後も元の文字列を返すべき")
 		})
 	}
 }
@@ -686,6 +690,68 @@ func TestNewIEnumerable(t *testing.T) {
 			NewRegexpIterator("", regexp.MustCompile("a"), false),
 			false,
 		},
+		{
+			"to be FixedWidthIterator",
+			args{
+				option.Option{
+					FixedWidth: option.FixedWidth{Widths: []int{2, 3, -1}, DisplayWidth: true},
+				},
+			},
+			NewFixedWidthIterator("", []int{0, 2, 5}, -1, true, false),
+			false,
+		},
+		{
+			"to be LTSVIterator",
+			args{
+				option.Option{
+					LtsvOption: option.LtsvOption{Ltsv: true},
+				},
+			},
+			NewLTSVIterator(""),
+			false,
+		},
+		{
+			"to be LogfmtIterator",
+			args{
+				option.Option{
+					LogfmtOption: option.LogfmtOption{Logfmt: true},
+				},
+			},
+			NewLogfmtIterator(""),
+			false,
+		},
+		{
+			"to be QuotedIterator",
+			args{
+				option.Option{
+					QuotedOption: option.QuotedOption{Quoted: true, QuoteChars: `"'`, NoEscape: true},
+				},
+			},
+			NewQuotedIterator("", `"'`, false),
+			false,
+		},
+		{
+			"to be RegexpIterator splitting on newlines in paragraph mode",
+			args{
+				option.Option{
+					DelimiterOption: option.DelimiterOption{InputDelimiter: "."},
+					RecordOption:    option.RecordOption{Paragraph: true},
+				},
+			},
+			NewRegexpIterator("", regexp.MustCompile(`\n|(?:\.)`), false),
+			false,
+		},
+		{
+			"to be PreSplitIterator splitting on newlines in paragraph mode with regexp",
+			args{
+				option.Option{
+					DelimiterOption: option.DelimiterOption{UseRegexp: true, SplitBefore: true, InputDelimiter: ",+"},
+					RecordOption:    option.RecordOption{Paragraph: true},
+				},
+			},
+			NewPreSplitByRegexpIterator("", regexp.MustCompile(`\n|(?:,+)`), false),
+			false,
+		},
 		{
 			"fail on regexp is not invalid",
 			args{
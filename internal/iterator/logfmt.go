@@ -0,0 +1,110 @@
+package iterator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LogfmtIterator は logfmt(key=value を空白で区切ったもの)の1行を読むイテレーター
+// 要素はキーを取り除いた値で、"..." でクォートされた値は空白を含んでいても1つの値になる
+type LogfmtIterator struct {
+	*PreSplitIterator
+	labelIndex
+}
+
+func NewLogfmtIterator(s string) *LogfmtIterator {
+	l := &LogfmtIterator{PreSplitIterator: &PreSplitIterator{sep: " "}}
+	l.Reset(s)
+	return l
+}
+
+func (l *LogfmtIterator) Reset(s string) {
+	keys, values := parseLogfmt(s, l.labels[:0])
+	l.set(keys)
+	l.PreSplitIterator.ResetFromArray(values)
+	l.line, l.hasLine = s, true
+}
+
+// ResetFromArray は a の要素をそれぞれ key=value として読む
+func (l *LogfmtIterator) ResetFromArray(a []string) {
+	keys, values := l.labels[:0], make([]string, 0, len(a))
+	for _, field := range a {
+		k, v := parseLogfmt(field, nil)
+		if len(k) == 0 {
+			k, v = []string{""}, []string{""}
+		}
+		keys, values = append(keys, k[0]), append(values, v[0])
+	}
+	l.set(keys)
+	l.PreSplitIterator.ResetFromArray(values)
+	l.line, l.hasLine = strings.Join(a, " "), true
+}
+
+// parseLogfmt は s を logfmt としてキーと値に分けて、keys の後ろに追加して返す
+//
+//	key=value        値はつぎの空白まで
+//	key="a \"b\" c"  クォートされた値は Go の文字列リテラルと同じエスケープが使える
+//	key= , key       値は空文字列
+//
+// 閉じていないクォートは行末までを値にする
+func parseLogfmt(s string, keys []string) ([]string, []string) {
+	var values []string
+	i := 0
+	for {
+		for i < len(s) && isLogfmtSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			return keys, values
+		}
+
+		start := i
+		for i < len(s) && s[i] != '=' && !isLogfmtSpace(s[i]) {
+			i++
+		}
+		keys = append(keys, s[start:i])
+		if i >= len(s) || s[i] != '=' {
+			values = append(values, "")
+			continue
+		}
+		i++
+
+		if i < len(s) && s[i] == '"' {
+			var v string
+			v, i = readLogfmtQuoted(s, i)
+			values = append(values, v)
+			continue
+		}
+
+		start = i
+		for i < len(s) && !isLogfmtSpace(s[i]) {
+			i++
+		}
+		values = append(values, s[start:i])
+	}
+}
+
+// readLogfmtQuoted は s[i] から始まるクォートされた値を読んで、エスケープを戻した値とつぎの位置を返す
+func readLogfmtQuoted(s string, i int) (string, int) {
+	end, escaped := i+1, false
+	for ; end < len(s); end++ {
+		if escaped {
+			escaped = false
+		} else if s[end] == '\\' {
+			escaped = true
+		} else if s[end] == '"' {
+			break
+		}
+	}
+
+	raw, next := s[i+1:min(end, len(s))], end+1
+	if v, err := strconv.Unquote(`"` + raw + `"`); err == nil {
+		return v, next
+	}
+	// Go の文字列リテラルとして読めないエスケープがあれば、そのままにする
+	return raw, next
+}
+
+func isLogfmtSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
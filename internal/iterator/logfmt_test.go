@@ -0,0 +1,59 @@
+package iterator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogfmtIterator_Reset(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		keys   []string
+		values []string
+	}{
+		{name: "simple", s: "level=info msg=hello dur=3ms", keys: []string{"level", "msg", "dur"}, values: []string{"info", "hello", "3ms"}},
+		{name: "quoted", s: `level=info msg="hello world" path="/a b"`, keys: []string{"level", "msg", "path"}, values: []string{"info", "hello world", "/a b"}},
+		{name: "escaped", s: `msg="say \"hi\"\tnow\\" n=1`, keys: []string{"msg", "n"}, values: []string{"say \"hi\"\tnow\\", "1"}},
+		{name: "empty values", s: "a= b c=\"\"  d", keys: []string{"a", "b", "c", "d"}, values: []string{"", "", "", ""}},
+		{name: "value with equal", s: "q=a=b", keys: []string{"q"}, values: []string{"a=b"}},
+		{name: "unterminated quote", s: `msg="hello world`, keys: []string{"msg"}, values: []string{"hello world"}},
+		{name: "invalid escape", s: `msg="a\qb" x=1`, keys: []string{"msg", "x"}, values: []string{`a\qb`, "1"}},
+		{name: "multibyte", s: "msg=\"こんにちは 世界\" user=太郎", keys: []string{"msg", "user"}, values: []string{"こんにちは 世界", "太郎"}},
+		{name: "empty line", s: "   ", keys: []string{}, values: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLogfmtIterator("x=1 y=2")
+			l.Reset(tt.s)
+			assert.Equal(t, tt.keys, l.Labels())
+			assert.Equal(t, tt.values, l.ToArray())
+			assert.Equal(t, tt.s, l.Line())
+		})
+	}
+}
+
+func TestLogfmtIterator_IndexOf(t *testing.T) {
+	l := NewLogfmtIterator(`level=info msg="a b" level=debug`)
+
+	idx, ok := l.IndexOf("msg")
+	assert.True(t, ok)
+	assert.Equal(t, 2, idx)
+
+	v, err := l.ElementAt(idx)
+	assert.NoError(t, err)
+	assert.Equal(t, "a b", v)
+
+	idx, _ = l.IndexOf("level")
+	assert.Equal(t, 1, idx, "同じキーは先に出てきた方を返すべき")
+
+	l.Reset("msg=x")
+	_, ok = l.IndexOf("level")
+	assert.False(t, ok)
+
+	l.ResetFromArray([]string{"a=1", `b="2 3"`})
+	assert.Equal(t, []string{"a", "b"}, l.Labels())
+	assert.Equal(t, []string{"1", "2 3"}, l.ToArray())
+}
@@ -0,0 +1,84 @@
+package iterator
+
+import "strings"
+
+// Labeled は label:value のように、カラムごとにラベルがついている行を読む IEnumerable
+// ラベルは行ごとに違っていてもよい
+type Labeled interface {
+	IEnumerable
+	// Labels はカラムのラベルを順番どおりに返す
+	Labels() []string
+	// IndexOf はラベルに対応するカラムの index を返す。1-indexed
+	IndexOf(label string) (int, bool)
+}
+
+// labelIndex は Labeled のラベルと、ラベルから index を引く表を持つやつ
+// 表は行を読むときに1度だけ作るので、クエリごとに行を見直さなくてよい
+type labelIndex struct {
+	labels []string
+	index  map[string]int
+}
+
+// set はラベルを入れ替える。同じラベルが複数あるときは先に出てきた方を IndexOf で返す
+func (l *labelIndex) set(labels []string) {
+	if l.index == nil {
+		l.index = map[string]int{}
+	}
+	clear(l.index)
+	for i, label := range labels {
+		if _, ok := l.index[label]; !ok {
+			l.index[label] = i + 1
+		}
+	}
+	l.labels = labels
+}
+
+func (l *labelIndex) Labels() []string {
+	return l.labels
+}
+
+func (l *labelIndex) IndexOf(label string) (int, bool) {
+	idx, ok := l.index[label]
+	return idx, ok
+}
+
+// LTSVIterator は LTSV(label:value をタブで区切ったもの)の1行を読むイテレーター
+// 要素はラベルを取り除いた値
+type LTSVIterator struct {
+	*PreSplitIterator
+	labelIndex
+}
+
+func NewLTSVIterator(s string) *LTSVIterator {
+	l := &LTSVIterator{PreSplitIterator: &PreSplitIterator{sep: "\t"}}
+	l.Reset(s)
+	return l
+}
+
+// Reset は s をタブで区切って、それぞれを最初の : でラベルと値に分ける。空行はカラムなしとして扱う
+func (l *LTSVIterator) Reset(s string) {
+	if len(s) == 0 {
+		l.ResetFromArray(nil)
+	} else {
+		l.ResetFromArray(strings.Split(s, "\t"))
+	}
+	l.line, l.hasLine = s, true
+}
+
+// ResetFromArray は a の要素を label:value としてラベルと値に分ける。: がなければラベルは空文字列になる
+func (l *LTSVIterator) ResetFromArray(a []string) {
+	labels := l.labels[:0]
+	values := make([]string, 0, len(a))
+	for _, field := range a {
+		label, value, found := strings.Cut(field, ":")
+		if !found {
+			label, value = "", field
+		}
+		labels = append(labels, label)
+		values = append(values, value)
+	}
+
+	l.set(labels)
+	l.PreSplitIterator.ResetFromArray(values)
+	l.line, l.hasLine = strings.Join(a, "\t"), true
+}
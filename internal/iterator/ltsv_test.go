@@ -0,0 +1,55 @@
+package iterator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLTSVIterator_Reset(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		labels []string
+		values []string
+	}{
+		{name: "labels", s: "host:127.0.0.1\treq:GET / HTTP/1.1\tstatus:200", labels: []string{"host", "req", "status"}, values: []string{"127.0.0.1", "GET / HTTP/1.1", "200"}},
+		{name: "value with colon", s: "time:12:34:56\tua:", labels: []string{"time", "ua"}, values: []string{"12:34:56", ""}},
+		{name: "no label", s: "host:a\tnolabel", labels: []string{"host", ""}, values: []string{"a", "nolabel"}},
+		{name: "empty line", s: "", labels: []string{}, values: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLTSVIterator("a:1\tb:2\tc:3")
+			l.Reset(tt.s)
+			assert.Equal(t, tt.labels, l.Labels())
+			assert.Equal(t, tt.values, l.ToArray())
+			assert.Equal(t, tt.s, l.Line())
+		})
+	}
+}
+
+func TestLTSVIterator_IndexOf(t *testing.T) {
+	l := NewLTSVIterator("host:a\tstatus:200\thost:b")
+
+	idx, ok := l.IndexOf("status")
+	assert.True(t, ok)
+	assert.Equal(t, 2, idx)
+
+	idx, ok = l.IndexOf("host")
+	assert.True(t, ok)
+	assert.Equal(t, 1, idx, "同じラベルは先に出てきた方を返すべき")
+
+	l.Reset("status:404")
+	_, ok = l.IndexOf("host")
+	assert.False(t, ok, "前の行のラベルが残っていてはいけない")
+
+	v, err := l.ElementAt(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "404", v)
+
+	l.ResetFromArray([]string{"a:1", "b:2"})
+	assert.Equal(t, []string{"1", "2"}, l.ToArray())
+	assert.Equal(t, "a:1\tb:2", l.Line())
+}
@@ -14,6 +14,9 @@ type PreSplitIterator struct {
 	reg         *regexp.Regexp
 	l           int
 	removeEmpty bool
+	// 分割する前の文字列。ResetFromArray されたときは Line で必要になるまで作らない
+	line    string
+	hasLine bool
 }
 
 func (p *PreSplitIterator) ElementAt(idx int) (string, error) {
@@ -69,6 +72,7 @@ func (p *PreSplitIterator) Reset(s string) {
 	} else {
 		p.ResetFromArray(p.reg.Split(s, -1))
 	}
+	p.line, p.hasLine = s, true
 }
 
 func (p *PreSplitIterator) ResetFromArray(a []string) {
@@ -81,6 +85,15 @@ func (p *PreSplitIterator) ResetFromArray(a []string) {
 	p.tail = 0
 	p.head = 0
 	p.l = len(p.a)
+	p.hasLine = false
+}
+
+// Line は分割する前の文字列を返す。ResetFromArray されたときは区切り文字で連結したものになる
+func (p *PreSplitIterator) Line() string {
+	if !p.hasLine {
+		p.line, p.hasLine = strings.Join(p.a, p.sep), true
+	}
+	return p.line
 }
 
 func NewPreSplitIterator(s, sep string, re bool) *PreSplitIterator {
@@ -176,6 +176,7 @@ func TestPreSplitIterator_Reset(t *testing.T) {
 			as.Equal(0, p.tail)
 			as.Equal(4, p.l)
 			as.Equal([]string{"a", "b", "c", "d"}, p.a)
+			as.Equal(tt.args.s, p.Line())
 			if p.reg == nil {
 				as.Nil(p.reg)
 				as.Equal(" ", p.sep)
@@ -187,6 +188,16 @@ func TestPreSplitIterator_Reset(t *testing.T) {
 	}
 }
 
+func TestPreSplitIterator_Line(t *testing.T) {
+	p := NewPreSplitIterator("", ",", false)
+
+	p.Reset("a,,b")
+	assert.Equal(t, "a,,b", p.Line())
+
+	p.ResetFromArray([]string{"x", "y z", "w"})
+	assert.Equal(t, "x,y z,w", p.Line())
+}
+
 func TestPreSplitIterator_Last(t *testing.T) {
 	type fields struct {
 		a           []string
@@ -0,0 +1,236 @@
+package iterator
+
+import (
+	"errors"
+	"strings"
+)
+
+// QuotedIterator は空白で分割するけど、クォートで囲まれた空白では分割しないイテレーター
+// "New York" 42 'a b' は New York, 42, a b になる。クォートはカラムの途中にあってもよい(a"b c" は ab c)
+// Iterator と同じく、前からも後ろからも必要なところまでしか分割しない
+type QuotedIterator struct {
+	// 前方から分割した結果 (index 0 = 1番目の要素)
+	front []string
+	// 後方から分割した結果 (index 0 = 最後の要素 = -1)
+	back []string
+	// オリジナルの文字列
+	s string
+	// 未分割の残り文字列
+	remaining string
+	// クォートに使う文字。どれも1バイトの文字
+	quotes string
+	// \ で次の文字をエスケープするかどうか
+	escape bool
+	// plain は残りの文字列にクォートもエスケープもないかどうか。0 ならまだ調べていない
+	// 後ろから分割するときは、クォートの外にいるかどうかが前から読まないとわからないので、plain でなければ残りをすべて分割する
+	plain int
+	// 最終的な分割結果。ToArray したときだけ書かれる
+	a []string
+}
+
+const (
+	plainUnknown = iota
+	plainYes
+	plainNo
+)
+
+func NewQuotedIterator(s, quotes string, escape bool) *QuotedIterator {
+	const initialCap = 16
+	q := &QuotedIterator{
+		front:  make([]string, 0, initialCap),
+		back:   make([]string, 0, initialCap),
+		quotes: quotes,
+		escape: escape,
+	}
+	q.Reset(s)
+	return q
+}
+
+func (q *QuotedIterator) Reset(s string) {
+	q.s = s
+	q.remaining = s
+	q.front = resetStringSlice(q.front)
+	q.back = resetStringSlice(q.back)
+	q.plain = plainUnknown
+	q.a = nil
+}
+
+// ResetFromArray は分割済みの a をそのまま要素にする
+func (q *QuotedIterator) ResetFromArray(a []string) {
+	q.Reset(strings.Join(a, " "))
+	q.remaining = ""
+	q.front = append(q.front, a...)
+}
+
+func (q *QuotedIterator) Line() string {
+	return q.s
+}
+
+// ElementAt は指定したインデックスの値を返す。1-indexed
+func (q *QuotedIterator) ElementAt(idx int) (string, error) {
+	if idx == 0 {
+		return "", errors.New(IndexOutOfRange)
+	}
+
+	if idx > 0 {
+		for len(q.front) < idx {
+			if _, ok := q.Next(); !ok {
+				break
+			}
+		}
+		if idx <= len(q.front) {
+			return q.front[idx-1], nil
+		}
+
+		// 残りがなければ back の方にあるかもしれない
+		if idx <= len(q.front)+len(q.back) {
+			backIdx := idx - len(q.front) - 1
+			return q.back[len(q.back)-1-backIdx], nil
+		}
+		return "", errors.New(IndexOutOfRange)
+	}
+
+	absIdx := -idx
+	for len(q.back) < absIdx {
+		if _, ok := q.Last(); !ok {
+			break
+		}
+	}
+	if absIdx <= len(q.back) {
+		return q.back[absIdx-1], nil
+	}
+
+	if absIdx <= len(q.front)+len(q.back) {
+		return q.front[len(q.front)-(absIdx-len(q.back))], nil
+	}
+	return "", errors.New(IndexOutOfRange)
+}
+
+// Next は先頭から次の要素を取り出す
+func (q *QuotedIterator) Next() (item string, ok bool) {
+	item, rest, ok := splitQuotedField(q.remaining, q.quotes, q.escape)
+	if !ok {
+		q.remaining = ""
+		return "", false
+	}
+
+	q.remaining = rest
+	q.front = append(q.front, item)
+	return item, true
+}
+
+// Last は末尾から要素を取り出す
+func (q *QuotedIterator) Last() (item string, ok bool) {
+	if q.plain == plainUnknown {
+		specials := q.quotes
+		if q.escape {
+			specials += `\`
+		}
+		q.plain = plainNo
+		if !strings.ContainsAny(q.remaining, specials) {
+			q.plain = plainYes
+		}
+	}
+
+	if q.plain == plainNo {
+		// 前から残りをすべて分割して back に積む
+		var rest []string
+		for item, r, ok := splitQuotedField(q.remaining, q.quotes, q.escape); ok; item, r, ok = splitQuotedField(r, q.quotes, q.escape) {
+			rest = append(rest, item)
+		}
+		q.remaining = ""
+		for i := len(rest) - 1; i >= 0; i-- {
+			q.back = append(q.back, rest[i])
+		}
+		q.plain = plainYes
+		if len(rest) == 0 {
+			return "", false
+		}
+		return rest[len(rest)-1], true
+	}
+
+	s := strings.TrimRight(q.remaining, blanks)
+	if len(s) == 0 {
+		q.remaining = ""
+		return "", false
+	}
+
+	m := strings.LastIndexAny(s, blanks)
+	item = s[m+1:]
+	q.remaining = s[:max(m, 0)]
+	q.back = append(q.back, item)
+	return item, true
+}
+
+func (q *QuotedIterator) ToArray() []string {
+	if q.a != nil {
+		return q.a
+	}
+
+	a := make([]string, 0, len(q.front)+len(q.back))
+	a = append(a, q.front...)
+	for item, r, ok := splitQuotedField(q.remaining, q.quotes, q.escape); ok; item, r, ok = splitQuotedField(r, q.quotes, q.escape) {
+		a = append(a, item)
+	}
+	for j := len(q.back) - 1; j >= 0; j-- {
+		a = append(a, q.back[j])
+	}
+
+	q.a = a
+	return a
+}
+
+// blanks はカラムを区切る空白
+const blanks = " \t"
+
+// splitQuotedField は s の先頭の空白を飛ばして、つぎのカラムを取り出す。クォートを取り除いて、エスケープを戻した値と残りの文字列を返す
+// 閉じていないクォートは行末までを囲んでいるものとして扱う
+func splitQuotedField(s, quotes string, escape bool) (field, rest string, ok bool) {
+	i := 0
+	for i < len(s) && strings.IndexByte(blanks, s[i]) >= 0 {
+		i++
+	}
+	if i == len(s) {
+		return "", "", false
+	}
+
+	start := i
+	// クォートやエスケープが出てくるまでは s を切り出すだけで済ませる
+	var sb strings.Builder
+	special := false
+	var quote byte
+	for ; i < len(s); i++ {
+		c := s[i]
+		if quote == 0 && strings.IndexByte(blanks, c) >= 0 {
+			break
+		}
+
+		isEscape := escape && c == '\\' && i+1 < len(s)
+		isQuote := (quote == 0 && strings.IndexByte(quotes, c) >= 0) || (quote != 0 && c == quote)
+		if !isEscape && !isQuote {
+			if special {
+				sb.WriteByte(c)
+			}
+			continue
+		}
+
+		if !special {
+			sb.WriteString(s[start:i])
+			special = true
+		}
+		switch {
+		case isEscape:
+			i++
+			sb.WriteByte(s[i])
+		case quote == 0:
+			quote = c
+		default:
+			quote = 0
+		}
+	}
+
+	if !special {
+		return s[start:i], s[i:], true
+	}
+	return sb.String(), s[i:], true
+}
@@ -0,0 +1,112 @@
+package iterator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotedIterator_ToArray(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		quotes string
+		escape bool
+		want   []string
+	}{
+		{name: "simple", s: "a  b\tc", quotes: `"'`, escape: true, want: []string{"a", "b", "c"}},
+		{name: "quoted", s: `"New York" 42 'a b'`, quotes: `"'`, escape: true, want: []string{"New York", "42", "a b"}},
+		{name: "quote in the middle", s: `a"b c"d e`, quotes: `"'`, escape: true, want: []string{"ab cd", "e"}},
+		{name: "other quote inside", s: `"it's" 'say "hi"'`, quotes: `"'`, escape: true, want: []string{"it's", `say "hi"`}},
+		{name: "empty quote", s: `a "" b`, quotes: `"'`, escape: true, want: []string{"a", "", "b"}},
+		{name: "escape", s: `a\ b "c\"d" \\`, quotes: `"'`, escape: true, want: []string{"a b", `c"d`, `\`}},
+		{name: "no escape", s: `C:\dir "a\" b`, quotes: `"'`, escape: false, want: []string{`C:\dir`, `a\`, "b"}},
+		{name: "trailing backslash", s: `a\`, quotes: `"'`, escape: true, want: []string{`a\`}},
+		{name: "unterminated quote", s: `a "b c`, quotes: `"'`, escape: true, want: []string{"a", "b c"}},
+		{name: "custom quote", s: "`a b` \"c", quotes: "`", escape: true, want: []string{"a b", `"c`}},
+		{name: "multibyte", s: `"こんにちは 世界" 太郎`, quotes: `"'`, escape: true, want: []string{"こんにちは 世界", "太郎"}},
+		{name: "blank line", s: "  \t ", quotes: `"'`, escape: true, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuotedIterator("x y", tt.quotes, tt.escape)
+			q.Reset(tt.s)
+			assert.Equal(t, tt.want, q.ToArray())
+			assert.Equal(t, tt.s, q.Line())
+		})
+	}
+}
+
+func TestQuotedIterator_ElementAt(t *testing.T) {
+	for _, s := range []string{
+		` a  b "c d" e f `,
+		" a  b c e f ",
+	} {
+		want := []string{"a", "b", "c d", "e", "f"}
+		if s == " a  b c e f " {
+			want = []string{"a", "b", "c", "e", "f"}
+		}
+
+		t.Run(s, func(t *testing.T) {
+			q := NewQuotedIterator(s, `"'`, true)
+			for i, w := range want {
+				got, err := q.ElementAt(i + 1)
+				assert.NoError(t, err)
+				assert.Equal(t, w, got)
+			}
+
+			q.Reset(s)
+			for i := range want {
+				got, err := q.ElementAt(-(i + 1))
+				assert.NoError(t, err)
+				assert.Equal(t, want[len(want)-1-i], got)
+			}
+
+			// 前と後ろを混ぜても同じ結果になる
+			q.Reset(s)
+			got, _ := q.ElementAt(1)
+			assert.Equal(t, want[0], got)
+			got, _ = q.ElementAt(-1)
+			assert.Equal(t, want[len(want)-1], got)
+			got, _ = q.ElementAt(3)
+			assert.Equal(t, want[2], got)
+			got, _ = q.ElementAt(-4)
+			assert.Equal(t, want[1], got)
+			assert.Equal(t, want, q.ToArray())
+
+			_, err := q.ElementAt(len(want) + 1)
+			assert.Error(t, err)
+			_, err = q.ElementAt(-len(want) - 1)
+			assert.Error(t, err)
+			_, err = q.ElementAt(0)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestQuotedIterator_Next(t *testing.T) {
+	q := NewQuotedIterator(`a "b c" d`, `"'`, true)
+
+	item, ok := q.Next()
+	assert.True(t, ok)
+	assert.Equal(t, "a", item)
+	assert.Equal(t, ` "b c" d`, q.remaining, "1番目を取り出すときは残りを分割しないべき")
+
+	item, ok = q.Last()
+	assert.True(t, ok)
+	assert.Equal(t, "d", item)
+
+	// クォートがあると後ろからは分割できないので、Last で残りがすべて back に入る
+	_, ok = q.Next()
+	assert.False(t, ok)
+	got, err := q.ElementAt(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "b c", got)
+
+	q.ResetFromArray([]string{"x y", "z"})
+	assert.Equal(t, []string{"x y", "z"}, q.ToArray())
+	got, err = q.ElementAt(-2)
+	assert.NoError(t, err)
+	assert.Equal(t, "x y", got)
+}
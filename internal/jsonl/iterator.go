@@ -0,0 +1,82 @@
+package jsonl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xztaityozx/sel/internal/iterator"
+)
+
+// Documenter は JSON のように構造を持った行を読む iterator.IEnumerable
+type Documenter interface {
+	iterator.IEnumerable
+	// Document はパースした行を返す。パースできなかったときはエラーを返す
+	Document() (Value, error)
+}
+
+// Iterator は JSON Lines の1行を読むイテレーター
+// 要素はトップレベルのオブジェクトの値をキーの順番に並べたもので、分割したあとは iterator.PreSplitIterator と同じ
+// キーをラベルにした iterator.Labeled でもある
+type Iterator struct {
+	*iterator.PreSplitIterator
+	line string
+	doc  Value
+	err  error
+}
+
+func NewIterator(s string) *Iterator {
+	j := &Iterator{PreSplitIterator: iterator.NewPreSplitIterator("", "", false)}
+	j.Reset(s)
+	return j
+}
+
+// Reset は s を JSON としてパースする。パースできなかったときは Document がエラーを返す
+// 空行は空のオブジェクトとして扱う
+func (j *Iterator) Reset(s string) {
+	j.line, j.doc, j.err = s, Value{}, nil
+	if len(strings.TrimSpace(s)) != 0 {
+		if j.doc, j.err = Parse(s); j.err != nil {
+			j.err = fmt.Errorf("'%s' is invalid JSON: %w", abbreviate(s), j.err)
+		}
+	}
+
+	var a []string
+	if j.err == nil && len(strings.TrimSpace(s)) != 0 {
+		for _, v := range j.doc.Elements() {
+			a = append(a, v.String())
+		}
+	}
+	j.PreSplitIterator.ResetFromArray(a)
+}
+
+// Line はパースする前の行を返す
+func (j *Iterator) Line() string {
+	return j.line
+}
+
+func (j *Iterator) Document() (Value, error) {
+	return j.doc, j.err
+}
+
+// Labels はトップレベルのオブジェクトのキーを返す。iterator.Labeled として、カラム名のクエリをキーで解決するのに使う
+func (j *Iterator) Labels() []string {
+	return j.doc.Keys()
+}
+
+func (j *Iterator) IndexOf(label string) (int, bool) {
+	for i, key := range j.doc.Keys() {
+		if key == label {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// abbreviate はエラーメッセージに入れる行が長すぎるときに後ろを省略する
+func abbreviate(s string) string {
+	const limit = 64
+	if r := []rune(s); len(r) > limit {
+		return string(r[:limit]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,77 @@
+package jsonl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterator_Reset(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []string
+		wantErr bool
+	}{
+		{name: "object", s: `{"b":1,"a":"x","c":{"d":[1,2]}}`, want: []string{"1", "x", `{"d":[1,2]}`}},
+		{name: "array", s: `[true,null,"y"]`, want: []string{"true", "null", "y"}},
+		{name: "scalar", s: `"z"`, want: []string{"z"}},
+		{name: "empty line", s: "", want: nil},
+		{name: "invalid", s: `{"a":`, want: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewIterator("")
+			j.Reset(tt.s)
+			assert.Equal(t, tt.want, j.ToArray())
+			assert.Equal(t, tt.s, j.Line())
+
+			_, err := j.Document()
+			if tt.wantErr {
+				assert.ErrorContains(t, err, tt.s, "エラーには行が含まれるべき")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIterator_Reset_LongLine(t *testing.T) {
+	line := `{"a":"` + strings.Repeat("x", 100)
+	_, err := NewIterator(line).Document()
+	assert.ErrorContains(t, err, line[:64]+"...")
+	assert.NotContains(t, err.Error(), line)
+}
+
+func TestIterator_ElementAt(t *testing.T) {
+	j := NewIterator(`{"id":7,"name":"alice"}`)
+
+	got, err := j.ElementAt(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", got)
+
+	got, err = j.ElementAt(-1)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", got)
+
+	_, err = j.ElementAt(3)
+	assert.Error(t, err)
+}
+
+func TestIterator_IndexOf(t *testing.T) {
+	j := NewIterator(`{"id":7,"name":"alice"}`)
+	assert.Equal(t, []string{"id", "name"}, j.Labels())
+
+	idx, ok := j.IndexOf("name")
+	assert.True(t, ok)
+	assert.Equal(t, 2, idx)
+
+	_, ok = j.IndexOf("age")
+	assert.False(t, ok)
+
+	j.Reset(`[1,2]`)
+	_, ok = j.IndexOf("id")
+	assert.False(t, ok, "オブジェクトでなければラベルはないべき")
+}
@@ -0,0 +1,152 @@
+package jsonl
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound は Path が指す値がないときのエラー
+var ErrNotFound = errors.New("not found")
+
+type stepKind int
+
+const (
+	stepKey stepKind = iota
+	stepIndex
+	// [] のように、配列の要素かオブジェクトの値をすべて選ぶ
+	stepIterate
+)
+
+type step struct {
+	kind  stepKind
+	key   string
+	index int
+}
+
+// Path は .user.id や .items[0].sku, .tags[] のような JSON の値を選ぶパス
+type Path struct {
+	src   string
+	steps []step
+}
+
+// ParsePath はパスをパースする。パスは . から始まる
+//
+//	.         値そのもの
+//	.key      オブジェクトのキー
+//	."key"    . や [ を含むキー
+//	[N]       配列の N 番目の要素。0-indexed で、負なら末尾から数える
+//	[]        配列のすべての要素かオブジェクトのすべての値
+func ParsePath(src string) (Path, error) {
+	if !strings.HasPrefix(src, ".") {
+		return Path{}, fmt.Errorf("%s: path must start with '.'", src)
+	}
+
+	p := Path{src: src}
+	rest := src[1:]
+	// afterDot は直前が . で、キーを書けるかどうか
+	afterDot := true
+	for len(rest) != 0 {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return Path{}, fmt.Errorf("%s: ']' is expected", src)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			switch {
+			case len(inner) == 0:
+				p.steps = append(p.steps, step{kind: stepIterate})
+			case inner[0] == '"':
+				key, err := strconv.Unquote(inner)
+				if err != nil {
+					return Path{}, fmt.Errorf("%s: %s is invalid key", src, inner)
+				}
+				p.steps = append(p.steps, step{kind: stepKey, key: key})
+			default:
+				idx, err := strconv.Atoi(inner)
+				if err != nil {
+					return Path{}, fmt.Errorf("%s: %s is invalid index", src, inner)
+				}
+				p.steps = append(p.steps, step{kind: stepIndex, index: idx})
+			}
+			rest = rest[end+1:]
+			afterDot = false
+		case rest[0] == '.':
+			if afterDot {
+				return Path{}, fmt.Errorf("%s: key is expected after '.'", src)
+			}
+			rest = rest[1:]
+			afterDot = true
+			if len(rest) == 0 {
+				return Path{}, fmt.Errorf("%s: key is expected after '.'", src)
+			}
+		case !afterDot:
+			return Path{}, fmt.Errorf("%s: '.' or '[' is expected", src)
+		case rest[0] == '"':
+			end := 1
+			for end < len(rest) && (rest[end] != '"' || rest[end-1] == '\\') {
+				end++
+			}
+			key, err := strconv.Unquote(rest[:min(end+1, len(rest))])
+			if err != nil {
+				return Path{}, fmt.Errorf("%s: %s is invalid key", src, rest)
+			}
+			p.steps = append(p.steps, step{kind: stepKey, key: key})
+			rest = rest[min(end+1, len(rest)):]
+			afterDot = false
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			p.steps = append(p.steps, step{kind: stepKey, key: rest[:end]})
+			rest = rest[end:]
+			afterDot = false
+		}
+	}
+
+	return p, nil
+}
+
+// Select は v からパスが指す値を返す
+// [] で展開した先で値がないときはその値を飛ばすが、それ以外で値がないときは ErrNotFound を返す
+func (p Path) Select(v Value) ([]Value, error) {
+	values := []Value{v}
+	iterated := false
+	for _, s := range p.steps {
+		next := make([]Value, 0, len(values))
+		for _, cur := range values {
+			var found bool
+			switch s.kind {
+			case stepKey:
+				var e Value
+				if e, found = cur.Get(s.key); found {
+					next = append(next, e)
+				}
+			case stepIndex:
+				var e Value
+				if e, found = cur.Index(s.index); found {
+					next = append(next, e)
+				}
+			case stepIterate:
+				if found = cur.kind == Array || cur.kind == Object; found {
+					next = append(next, cur.values...)
+				}
+			}
+
+			if !found && !iterated {
+				return nil, fmt.Errorf("%s: %w", p.src, ErrNotFound)
+			}
+		}
+
+		values = next
+		iterated = iterated || s.kind == stepIterate
+	}
+	return values, nil
+}
+
+func (p Path) String() string {
+	return p.src
+}
@@ -0,0 +1,87 @@
+package jsonl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    []step
+		wantErr bool
+	}{
+		{src: ".", want: nil},
+		{src: ".user.id", want: []step{{kind: stepKey, key: "user"}, {kind: stepKey, key: "id"}}},
+		{src: ".items[0].sku", want: []step{{kind: stepKey, key: "items"}, {kind: stepIndex, index: 0}, {kind: stepKey, key: "sku"}}},
+		{src: ".tags[]", want: []step{{kind: stepKey, key: "tags"}, {kind: stepIterate}}},
+		{src: ".[-1]", want: []step{{kind: stepIndex, index: -1}}},
+		{src: `."a.b"[""]`, want: []step{{kind: stepKey, key: "a.b"}, {kind: stepKey, key: ""}}},
+		{src: `.["x y"]`, want: []step{{kind: stepKey, key: "x y"}}},
+		{src: "user", wantErr: true},
+		{src: "..a", wantErr: true},
+		{src: ".a.", wantErr: true},
+		{src: ".a[0", wantErr: true},
+		{src: ".a[x]", wantErr: true},
+		{src: ".a[0]b", wantErr: true},
+		{src: `."a`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got, err := ParsePath(tt.src)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.steps)
+			assert.Equal(t, tt.src, got.String())
+		})
+	}
+}
+
+func TestPath_Select(t *testing.T) {
+	doc, err := Parse(`{"user":{"id":7,"name":"alice"},"items":[{"sku":"A1"},{"qty":2},{"sku":"C3"}],"tags":["x","y"],"empty":[]}`)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		src     string
+		want    []string
+		wantErr bool
+	}{
+		{src: ".user.id", want: []string{"7"}},
+		{src: ".user", want: []string{`{"id":7,"name":"alice"}`}},
+		{src: ".items[0].sku", want: []string{"A1"}},
+		{src: ".items[-1].sku", want: []string{"C3"}},
+		{src: ".items[].sku", want: []string{"A1", "C3"}},
+		{src: ".tags[]", want: []string{"x", "y"}},
+		{src: ".user[]", want: []string{"7", "alice"}},
+		{src: ".empty[]", want: []string{}},
+		{src: ".user.age", wantErr: true},
+		{src: ".items[3]", wantErr: true},
+		{src: ".items[1].sku", wantErr: true},
+		{src: ".tags.x", wantErr: true},
+		{src: ".user.id[]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			p, err := ParsePath(tt.src)
+			assert.NoError(t, err)
+
+			values, err := p.Select(doc)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrNotFound)
+				return
+			}
+			assert.NoError(t, err)
+			got := make([]string, 0, len(values))
+			for _, v := range values {
+				got = append(got, v.String())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package jsonl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kind は JSON の値の種類
+type Kind int
+
+const (
+	Null Kind = iota
+	Bool
+	Number
+	String
+	Array
+	Object
+)
+
+// Value は JSON の値。オブジェクトのキーの順番を覚えておくために encoding/json の map は使わない
+type Value struct {
+	kind Kind
+	// Bool, Number, String のときの値。Number はパースしたときの表記のまま持っておく
+	scalar string
+	// Object のときのキー
+	keys []string
+	// Array の要素か Object の値
+	values []Value
+}
+
+// NewString は文字列の Value を返す
+func NewString(s string) Value {
+	return Value{kind: String, scalar: s}
+}
+
+// NewNumber は数値の Value を返す。n は JSON の数値として正しい表記でなければならない
+func NewNumber(n string) Value {
+	return Value{kind: Number, scalar: n}
+}
+
+// NewBool は真偽値の Value を返す
+func NewBool(b bool) Value {
+	if b {
+		return Value{kind: Bool, scalar: "true"}
+	}
+	return Value{kind: Bool, scalar: "false"}
+}
+
+// NewNull は null の Value を返す
+func NewNull() Value {
+	return Value{kind: Null}
+}
+
+// NewArray は values を要素にした配列の Value を返す
+func NewArray(values ...Value) Value {
+	return Value{kind: Array, values: values}
+}
+
+// NewObject は keys と values を順番に対応させたオブジェクトの Value を返す。keys と values は同じ長さでなければならない
+func NewObject(keys []string, values []Value) Value {
+	return Value{kind: Object, keys: keys, values: values}
+}
+
+var numberValidator = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][+-]?\d+)?$`)
+
+// Infer は s を JSON の数値、真偽値、null として読めるならその Value を、読めなければ文字列の Value を返す
+// 空文字列は null にする。007 や +1 のように JSON の数値として正しくない表記は文字列のまま
+func Infer(s string) Value {
+	switch s {
+	case "", "null":
+		return NewNull()
+	case "true":
+		return NewBool(true)
+	case "false":
+		return NewBool(false)
+	}
+	if numberValidator.MatchString(s) {
+		return NewNumber(s)
+	}
+	return NewString(s)
+}
+
+// Parse は s を JSON としてパースする
+func Parse(s string) (Value, error) {
+	d := json.NewDecoder(strings.NewReader(s))
+	d.UseNumber()
+
+	v, err := parseValue(d)
+	if err != nil {
+		return Value{}, err
+	}
+	if _, err := d.Token(); err == nil {
+		return Value{}, fmt.Errorf("unexpected data after JSON value: %s", s)
+	}
+	return v, nil
+}
+
+func parseValue(d *json.Decoder) (Value, error) {
+	t, err := d.Token()
+	if err != nil {
+		return Value{}, err
+	}
+
+	switch t := t.(type) {
+	case json.Delim:
+		var v Value
+		if t == '{' {
+			v.kind = Object
+		} else {
+			v.kind = Array
+		}
+
+		for d.More() {
+			if v.kind == Object {
+				k, err := d.Token()
+				if err != nil {
+					return Value{}, err
+				}
+				v.keys = append(v.keys, k.(string))
+			}
+
+			e, err := parseValue(d)
+			if err != nil {
+				return Value{}, err
+			}
+			v.values = append(v.values, e)
+		}
+
+		// 閉じ括弧を読む
+		if _, err := d.Token(); err != nil {
+			return Value{}, err
+		}
+		return v, nil
+	case string:
+		return NewString(t), nil
+	case json.Number:
+		return NewNumber(t.String()), nil
+	case bool:
+		return NewBool(t), nil
+	}
+	return NewNull(), nil
+}
+
+func (v Value) Kind() Kind {
+	return v.kind
+}
+
+// Get は Object のキー k の値を返す
+func (v Value) Get(k string) (Value, bool) {
+	if v.kind != Object {
+		return Value{}, false
+	}
+	for i, key := range v.keys {
+		if key == k {
+			return v.values[i], true
+		}
+	}
+	return Value{}, false
+}
+
+// Index は Array の i 番目の要素を返す。0-indexed で、負なら末尾から数える
+func (v Value) Index(i int) (Value, bool) {
+	if v.kind != Array {
+		return Value{}, false
+	}
+	if i < 0 {
+		i += len(v.values)
+	}
+	if i < 0 || i >= len(v.values) {
+		return Value{}, false
+	}
+	return v.values[i], true
+}
+
+// Keys は Object のキーを順番どおりに返す
+func (v Value) Keys() []string {
+	return v.keys
+}
+
+// Elements は Array の要素か Object の値を順番どおりに返す。それ以外は値そのものだけを返す
+func (v Value) Elements() []Value {
+	if v.kind == Array || v.kind == Object {
+		return v.values
+	}
+	return []Value{v}
+}
+
+// String は値を出力するときの文字列を返す。jq -r と同じで、文字列はクォートせず、配列とオブジェクトは JSON にする
+func (v Value) String() string {
+	switch v.kind {
+	case Null:
+		return "null"
+	case Array, Object:
+		b, _ := v.MarshalJSON()
+		return string(b)
+	}
+	return v.scalar
+}
+
+// MarshalJSON はオブジェクトのキーの順番を保ったまま JSON にする
+func (v Value) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := v.encode(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (v Value) encode(buf *bytes.Buffer) error {
+	switch v.kind {
+	case Null:
+		buf.WriteString("null")
+	case Bool, Number:
+		buf.WriteString(v.scalar)
+	case String:
+		return encodeString(buf, v.scalar)
+	case Array:
+		buf.WriteByte('[')
+		for i, e := range v.values {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			if err := e.encode(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case Object:
+		buf.WriteByte('{')
+		for i, k := range v.keys {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeString(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := v.values[i].encode(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+	return nil
+}
+
+// encodeString は s を JSON の文字列にする。json.Marshal と違って <>& はエスケープしない
+func encodeString(buf *bytes.Buffer, s string) error {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	buf.Write(bytes.TrimRight(b.Bytes(), "\n"))
+	return nil
+}
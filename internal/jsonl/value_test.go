@@ -0,0 +1,96 @@
+package jsonl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{src: `{"b":1,"a":{"y":[1,2.50,"x"],"x":null}}`, want: `{"b":1,"a":{"y":[1,2.50,"x"],"x":null}}`},
+		{src: ` { "z" : true , "a" : false } `, want: `{"z":true,"a":false}`},
+		{src: `[1,"<a&b>"]`, want: `[1,"<a&b>"]`},
+		{src: `"text"`, want: "text"},
+		{src: `1e3`, want: "1e3"},
+		{src: `null`, want: "null"},
+		{src: `{"a":1`, wantErr: true},
+		{src: `{"a":1} {"b":2}`, wantErr: true},
+		{src: `{a:1}`, wantErr: true},
+		{src: ``, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got, err := Parse(tt.src)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestValue_Accessors(t *testing.T) {
+	v, err := Parse(`{"b":1,"a":[10,20,30]}`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, Object, v.Kind())
+	assert.Equal(t, []string{"b", "a"}, v.Keys())
+	assert.Len(t, v.Elements(), 2)
+
+	b, ok := v.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, Number, b.Kind())
+	_, ok = v.Get("c")
+	assert.False(t, ok)
+
+	a, _ := v.Get("a")
+	e, ok := a.Index(-1)
+	assert.True(t, ok)
+	assert.Equal(t, "30", e.String())
+	_, ok = a.Index(3)
+	assert.False(t, ok)
+	_, ok = b.Index(0)
+	assert.False(t, ok)
+
+	assert.Equal(t, []Value{b}, b.Elements())
+}
+
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Value
+	}{
+		{s: "1", want: NewNumber("1")},
+		{s: "-2.50", want: NewNumber("-2.50")},
+		{s: "1e-3", want: NewNumber("1e-3")},
+		{s: "007", want: NewString("007")},
+		{s: "+1", want: NewString("+1")},
+		{s: "1.", want: NewString("1.")},
+		{s: "true", want: NewBool(true)},
+		{s: "False", want: NewString("False")},
+		{s: "null", want: NewNull()},
+		{s: "", want: NewNull()},
+		{s: "abc", want: NewString("abc")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			assert.Equal(t, tt.want, Infer(tt.s))
+		})
+	}
+}
+
+func TestNewObject(t *testing.T) {
+	v := NewObject([]string{"b", "a\"<"}, []Value{NewArray(NewNumber("1"), NewNull()), NewString("x\ny")})
+	got, err := v.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"b":[1,null],"a\"<":"x\ny"}`, string(got))
+}
@@ -4,9 +4,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"text/template"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/spf13/viper"
+	"github.com/xztaityozx/sel/internal/archive"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	textunicode "golang.org/x/text/encoding/unicode"
 )
 
 // Option is commandline options
@@ -17,6 +27,30 @@ type Option struct {
 	InputFiles
 	// XSV support
 	Xsv
+	// --widths, --cuts, --aligned
+	FixedWidth
+	// --jsonl
+	JsonlOption
+	// --ltsv
+	LtsvOption
+	// --logfmt
+	LogfmtOption
+	// --quoted, --quote-chars, --no-escape
+	QuotedOption
+	// --header, --keep-header, --emit-header
+	HeaderOption
+	// -z, --record-separator, --output-record-separator, --record-start, --continuation
+	RecordOption
+	// --input-encoding, --output-encoding
+	EncodingOption
+	// --no-decompress
+	DecompressOption
+	// --where
+	WhereOption
+	// --rows
+	RowsOption
+	// --output-format
+	OutputOption
 	// --template
 	Template *template.Template
 }
@@ -45,6 +79,7 @@ type InputFiles struct {
 }
 
 // Enumerate /path/to/input/files
+// bundle.zip:reports/*.csv のようにアーカイブの中のファイルを指定したときは、アーカイブのパスだけを展開して :reports/*.csv はそのまま残す
 func (ifs InputFiles) Enumerate() ([]string, error) {
 	if len(ifs.Files) == 0 {
 		return nil, fmt.Errorf("there are no files")
@@ -53,6 +88,7 @@ func (ifs InputFiles) Enumerate() ([]string, error) {
 	var rt []string
 
 	for _, v := range ifs.Files {
+		v, member, isMember := archive.Split(v)
 		expanded, err := filepath.Glob(v)
 		if err != nil {
 			return nil, err
@@ -68,6 +104,9 @@ func (ifs InputFiles) Enumerate() ([]string, error) {
 				return nil, fmt.Errorf("%s is directory", p)
 			}
 
+			if isMember {
+				p += archive.Separator + member
+			}
 			rt = append(rt, p)
 		}
 	}
@@ -92,9 +131,49 @@ const (
 	NameIgnoreMissing   = "ignore-missing"
 	NameFillMissing     = "fill-missing"
 	NameTemplate        = "template"
+	NameHeader          = "header"
+	NameWhere           = "where"
+	NameInvertWhere     = "invert-where"
+	NameRows            = "rows"
+	NameWidths          = "widths"
+	NameCuts            = "cuts"
+	NameDisplayWidth    = "display-width"
+	NameAligned         = "aligned"
+	NameAlignedSample   = "aligned-sample"
+	NameJsonl           = "jsonl"
+	NameOutputFormat    = "output-format"
+	NameInferTypes      = "infer-types"
+	NameJSONObject      = "json-object"
+	NameOutputCsv       = "output-csv"
+	NameOutputTsv       = "output-tsv"
+	NameOutputQuote     = "output-quote"
+	NameCRLF            = "crlf"
+	NameTableSample     = "table-sample"
+	NameLtsv            = "ltsv"
+	NameLogfmt          = "logfmt"
+	NameQuoted          = "quoted"
+	NameQuoteChars      = "quote-chars"
+	NameNoEscape        = "no-escape"
+	NameCsvDelimiter    = "csv-delimiter"
+	NameCsvComment      = "csv-comment"
+	NameCsvLazyQuotes   = "csv-lazy-quotes"
+	NameCsvTrimSpace    = "csv-trim-leading-space"
+	NameCsvFields       = "csv-fields-per-record"
+	NameKeepHeader      = "keep-header"
+	NameEmitHeader      = "emit-header"
+	NameNullData        = "null-data"
+	NameRecordSep       = "record-separator"
+	NameOutputRecordSep = "output-record-separator"
+	NameRecordStart     = "record-start"
+	NameContinuation    = "continuation"
+	NameRecordJoin      = "record-join"
+	NameInputEncoding   = "input-encoding"
+	NameOutputEncoding  = "output-encoding"
+	NameNoDecompress    = "no-decompress"
 
 	DefaultFillMissing = ""
 	DefaultTemplate    = ""
+	DefaultQuoteChars  = `"'`
 )
 
 type SplitStrategy int
@@ -113,17 +192,324 @@ func GetOptionNames() []string {
 		NameCsv,
 		NameTsv,
 		NameTemplate,
+		NameHeader,
+		NameWhere,
+		NameInvertWhere,
+		NameRows,
+		NameWidths,
+		NameCuts,
+		NameDisplayWidth,
+		NameAligned,
+		NameAlignedSample,
+		NameJsonl,
+		NameOutputFormat,
+		NameInferTypes,
+		NameJSONObject,
+		NameOutputCsv,
+		NameOutputTsv,
+		NameOutputQuote,
+		NameCRLF,
+		NameTableSample,
+		NameLtsv,
+		NameLogfmt,
+		NameQuoted,
+		NameQuoteChars,
+		NameNoEscape,
+		NameCsvDelimiter,
+		NameCsvComment,
+		NameCsvLazyQuotes,
+		NameCsvTrimSpace,
+		NameCsvFields,
+		NameKeepHeader,
+		NameEmitHeader,
+		NameNullData,
+		NameRecordSep,
+		NameOutputRecordSep,
+		NameRecordStart,
+		NameContinuation,
+		NameRecordJoin,
+		NameInputEncoding,
+		NameOutputEncoding,
+		NameNoDecompress,
+	}
+}
+
+// HeaderOption is setting for --header option
+type HeaderOption struct {
+	// --header
+	UseHeader bool
+	// --keep-header。最初の行を --where や --rows によらず選択して書き出す。複数のファイルでも最初の1回だけ
+	KeepHeader bool
+	// --emit-header。書き出すカラムの名前をヘッダー行として最初に1回だけ書き出す
+	EmitHeader bool
+}
+
+// IsHeaderOutput は --keep-header か --emit-header でヘッダー行を書き出すかどうかを返す
+func (h HeaderOption) IsHeaderOutput() bool {
+	return h.KeepHeader || h.EmitHeader
+}
+
+// RecordOption is setting for record separators
+type RecordOption struct {
+	// --record-separator。エスケープを戻した入力のレコードの区切り。空なら改行。-z のときは NUL
+	RecordSeparator string
+	// --record-separator を /regexp/ で指定したときの正規表現
+	RecordRegexp *regexp.Regexp
+	// --record-separator '' のときは awk と同じように、1行以上の空行でレコードを区切って、改行でもカラムを区切る
+	Paragraph bool
+	// --output-record-separator。エスケープを戻した出力のレコードの区切り。空なら改行。-z のときは NUL
+	OutputRecordSeparator string
+	// --record-start。これにマッチするレコードから新しいレコードを始めて、そうでなければ前のレコードにつなげる
+	RecordStart *regexp.Regexp
+	// --continuation。これにマッチするレコードは前のレコードにつなげる
+	Continuation *regexp.Regexp
+	// --record-join。レコードをつなげるときに間に挟む文字列。--record-start か --continuation のときだけ設定される
+	RecordJoin string
+}
+
+// IsMultiline は --record-start か --continuation で複数のレコードを1つにつなげるかどうかを返す
+func (r RecordOption) IsMultiline() bool {
+	return r.RecordStart != nil || r.Continuation != nil
+}
+
+// OutputLineEnd は出力のレコードの終わりに書き込む文字列を返す
+func (r RecordOption) OutputLineEnd() string {
+	if len(r.OutputRecordSeparator) == 0 {
+		return "\n"
+	}
+	return r.OutputRecordSeparator
+}
+
+// IsRecordSeparated は入力のレコードを改行以外で区切るかどうかを返す
+func (r RecordOption) IsRecordSeparated() bool {
+	return len(r.RecordSeparator) != 0 || r.RecordRegexp != nil || r.Paragraph
+}
+
+// separatorUnescaper は --record-separator や --output-record-separator の、シェルで打ちにくい文字のエスケープを戻す
+var separatorUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\r`, "\r", `\t`, "\t", `\0`, "\x00")
+
+// parseRecord は -z, --record-separator, --output-record-separator を読む
+// --record-separator は /regexp/ なら正規表現で、空文字列なら段落モードになる
+func parseRecord(v *viper.Viper) (RecordOption, error) {
+	var r RecordOption
+	if v.GetBool(NameNullData) {
+		r.RecordSeparator = "\x00"
+		r.OutputRecordSeparator = "\x00"
+	}
+
+	if v.IsSet(NameRecordSep) {
+		sep := v.GetString(NameRecordSep)
+		switch {
+		case len(sep) == 0:
+			r.Paragraph = true
+		case len(sep) > 2 && strings.HasPrefix(sep, "/") && strings.HasSuffix(sep, "/"):
+			re, err := regexp.Compile(sep[1 : len(sep)-1])
+			if err != nil {
+				return RecordOption{}, fmt.Errorf("--%s: %w", NameRecordSep, err)
+			}
+			if re.MatchString("") {
+				return RecordOption{}, fmt.Errorf("--%s: %s matches empty string", NameRecordSep, sep)
+			}
+			r.RecordRegexp = re
+		default:
+			r.RecordSeparator = separatorUnescaper.Replace(sep)
+		}
+	}
+
+	if v.IsSet(NameOutputRecordSep) {
+		r.OutputRecordSeparator = separatorUnescaper.Replace(v.GetString(NameOutputRecordSep))
+	}
+
+	var err error
+	if r.RecordStart, err = parseRecordRegexp(NameRecordStart, v.GetString(NameRecordStart)); err != nil {
+		return RecordOption{}, err
+	}
+	if r.Continuation, err = parseRecordRegexp(NameContinuation, v.GetString(NameContinuation)); err != nil {
+		return RecordOption{}, err
+	}
+	if r.IsMultiline() {
+		r.RecordJoin = "\n"
+		if v.IsSet(NameRecordJoin) {
+			r.RecordJoin = separatorUnescaper.Replace(v.GetString(NameRecordJoin))
+		}
 	}
+
+	// CSV/TSV はレコードの区切りも encoding/csv が決める
+	if (r.IsRecordSeparated() || r.IsMultiline()) && (v.GetBool(NameCsv) || v.GetBool(NameTsv) || v.GetString(NameCsvDelimiter) != "") {
+		return RecordOption{}, fmt.Errorf("-z, --%s, --%s and --%s can not be used with CSV/TSV input", NameRecordSep, NameRecordStart, NameContinuation)
+	}
+	return r, nil
+}
+
+// parseRecordRegexp は --record-start や --continuation の正規表現をコンパイルする。/regexp/ のように / で囲んでもよい。空なら nil を返す
+func parseRecordRegexp(name, s string) (*regexp.Regexp, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	if len(s) > 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		s = s[1 : len(s)-1]
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", name, err)
+	}
+	return re, nil
+}
+
+// EncodingOption is setting for --input-encoding and --output-encoding
+type EncodingOption struct {
+	// --input-encoding。nil なら UTF-8 のまま読む
+	InputEncoding encoding.Encoding
+	// --output-encoding。nil なら UTF-8 のまま書き出す
+	OutputEncoding encoding.Encoding
+}
+
+// DecompressOption is setting for --no-decompress option
+type DecompressOption struct {
+	// --no-decompress。gzip や bzip2 で圧縮された入力を展開せずにそのまま読む
+	NoDecompress bool
+}
+
+// encodings は --input-encoding と --output-encoding で指定できる文字コード。UTF-8 は変換しないので nil
+// UTF-16 の BOM は読むときに見るので、ここでは BOM を使わないものにしておく
+var encodings = map[string]encoding.Encoding{
+	"utf-8":        nil,
+	"utf8":         nil,
+	"shift-jis":    japanese.ShiftJIS,
+	"sjis":         japanese.ShiftJIS,
+	"cp932":        japanese.ShiftJIS,
+	"windows-31j":  japanese.ShiftJIS,
+	"euc-jp":       japanese.EUCJP,
+	"eucjp":        japanese.EUCJP,
+	"iso-2022-jp":  japanese.ISO2022JP,
+	"jis":          japanese.ISO2022JP,
+	"utf-16le":     textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM),
+	"utf-16be":     textunicode.UTF16(textunicode.BigEndian, textunicode.IgnoreBOM),
+	"latin-1":      charmap.ISO8859_1,
+	"latin1":       charmap.ISO8859_1,
+	"iso-8859-1":   charmap.ISO8859_1,
+	"windows-1252": charmap.Windows1252,
+	"cp1252":       charmap.Windows1252,
+}
+
+// parseEncoding は文字コードの名前から encoding.Encoding を返す。大文字小文字と - と _ は区別しない
+func parseEncoding(name, value string) (encoding.Encoding, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	enc, ok := encodings[strings.ReplaceAll(strings.ToLower(value), "_", "-")]
+	if !ok {
+		return nil, fmt.Errorf("--%s: %s is not supported (utf-8, shift_jis, euc-jp, iso-2022-jp, utf-16le, utf-16be, latin-1, windows-1252)", name, value)
+	}
+	return enc, nil
+}
+
+// WhereOption is setting for --where option
+type WhereOption struct {
+	// --where
+	Where []string
+	// --invert-where
+	InvertWhere bool
+}
+
+// RowsOption is setting for --rows option
+type RowsOption struct {
+	// --rows
+	Rows string
+}
+
+// OutputOption is setting for --output-format option
+type OutputOption struct {
+	// --output-format。空なら -D で区切って出力する
+	OutputFormat string
+	// --infer-types。数値や真偽値を JSON の文字列ではなくその型で出力する
+	InferTypes bool
+	// --json-object。--header がなくても行をオブジェクトとして出力する
+	JSONObject bool
+	// --output-quote。CSV/TSV で出力するときにどのカラムをクォートするか。空なら QuoteMinimal
+	Quote string
+	// --crlf。CSV/TSV で出力するときの改行を CRLF にする
+	CRLF bool
+	// --table-sample。表で出力するときに、この行数を読んだらカラムの幅を決めて書き出し始める。0 ならすべての行を読んでから
+	TableSample int
+	// CSV で出力するときの区切り文字。0 なら , を使う。--csv-delimiter の入力を同じ形式で出力するときに設定される
+	Comma rune
+}
+
+const (
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+	FormatTSV   = "tsv"
+	// FormatTable は罫線で囲んだ表
+	FormatTable = "table"
+	// FormatMarkdown はカラムの寄せ方を :--- や ---: で書く Markdown の表
+	FormatMarkdown = "markdown"
+	// FormatGithub は寄せ方を書かない GitHub Flavored Markdown の表
+	FormatGithub = "github"
+	// FormatLTSV はカラムの名前をラベルにした LTSV
+	FormatLTSV = "ltsv"
+	// FormatLogfmt はカラムの名前をキーにした logfmt
+	FormatLogfmt = "logfmt"
+
+	// QuoteMinimal は区切り文字や " や改行を含むカラムだけをクォートする
+	QuoteMinimal = "minimal"
+	// QuoteAll はすべてのカラムをクォートする
+	QuoteAll = "all"
+	// QuoteNonNumeric は数値でないカラムをクォートする
+	QuoteNonNumeric = "non-numeric"
+)
+
+// IsJSON は --output-format が JSON のどちらかかどうかを返す
+func (o OutputOption) IsJSON() bool {
+	return o.OutputFormat == FormatJSON || o.OutputFormat == FormatJSONL
+}
+
+// IsNamed は --output-format がカラムの名前を使う形式かどうかを返す
+func (o OutputOption) IsNamed() bool {
+	return o.IsJSON() || o.IsTable() || o.OutputFormat == FormatLTSV || o.OutputFormat == FormatLogfmt
+}
+
+// IsTable は --output-format が表のどれかかどうかを返す
+func (o OutputOption) IsTable() bool {
+	return o.OutputFormat == FormatTable || o.OutputFormat == FormatMarkdown || o.OutputFormat == FormatGithub
+}
+
+// IsXsvOutput は --output-format が CSV/TSV のどちらかかどうかと、そのときの区切り文字を返す
+func (o OutputOption) IsXsvOutput() (bool, rune) {
+	if o.OutputFormat == FormatCSV && o.Comma != 0 {
+		return true, o.Comma
+	}
+	switch o.OutputFormat {
+	case FormatCSV:
+		return true, ','
+	case FormatTSV:
+		return true, '\t'
+	}
+	return false, ','
 }
 
 // Xsv is option group for xsv support
 type Xsv struct {
 	Csv bool
 	Tsv bool
+	// --csv-delimiter。0 なら --csv/--tsv の区切り文字を使う
+	Delimiter rune
+	// --csv-comment。この文字で始まる行を読み飛ばす。0 なら読み飛ばさない
+	Comment rune
+	// --csv-lazy-quotes。クォートされていないカラムの " や、クォートされたカラムの中の " をそのまま読む
+	LazyQuotes bool
+	// --csv-trim-leading-space。カラムの先頭の空白を取り除く
+	TrimLeadingSpace bool
+	// --csv-fields-per-record。encoding/csv と同じで、0 なら最初の行と同じ数、負ならいくつでもよい
+	FieldsPerRecord int
 }
 
+// IsXsv は入力を CSV/TSV として読むかどうかと、そのときの区切り文字を返す。--csv-delimiter だけでも CSV として読む
 func (x Xsv) IsXsv() (bool, rune) {
-	if x.Csv {
+	if x.Delimiter != 0 {
+		return true, x.Delimiter
+	} else if x.Csv {
 		return true, ','
 	} else if x.Tsv {
 		return true, '\t'
@@ -132,6 +518,181 @@ func (x Xsv) IsXsv() (bool, rune) {
 	}
 }
 
+// JsonlOption is setting for --jsonl option
+type JsonlOption struct {
+	// --jsonl
+	Jsonl bool
+}
+
+// LtsvOption is setting for --ltsv option
+type LtsvOption struct {
+	// --ltsv
+	Ltsv bool
+}
+
+// LogfmtOption is setting for --logfmt option
+type LogfmtOption struct {
+	// --logfmt
+	Logfmt bool
+}
+
+// QuotedOption is setting for --quoted option
+type QuotedOption struct {
+	// --quoted
+	Quoted bool
+	// --quote-chars。どれかで囲まれた空白では分割しない。--quoted のときだけ設定される
+	QuoteChars string
+	// --no-escape。\ を普通の文字として扱う
+	NoEscape bool
+}
+
+// parseCsvRune は --csv-delimiter や --csv-comment の値を1文字の rune にする。空なら 0 を返す。シェルで打ちにくいので \t はタブにする
+// encoding/csv が受け付けない文字(改行や ")はエラーにする
+func parseCsvRune(name, s string) (rune, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if s == `\t` {
+		return '\t', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("--%s: %q must be a single character other than '\"', CR and LF", name, s)
+	}
+	return r, nil
+}
+
+// parseXsv は CSV/TSV の入力に関するフラグを読む。--csv-* は CSV/TSV として読むときにしか使えない
+func parseXsv(v *viper.Viper) (Xsv, error) {
+	x := Xsv{
+		Csv:              v.GetBool(NameCsv),
+		Tsv:              v.GetBool(NameTsv),
+		LazyQuotes:       v.GetBool(NameCsvLazyQuotes),
+		TrimLeadingSpace: v.GetBool(NameCsvTrimSpace),
+		FieldsPerRecord:  v.GetInt(NameCsvFields),
+	}
+
+	var err error
+	if x.Delimiter, err = parseCsvRune(NameCsvDelimiter, v.GetString(NameCsvDelimiter)); err != nil {
+		return Xsv{}, err
+	}
+	if x.Comment, err = parseCsvRune(NameCsvComment, v.GetString(NameCsvComment)); err != nil {
+		return Xsv{}, err
+	}
+
+	ok, comma := x.IsXsv()
+	if !ok && (x.Comment != 0 || x.LazyQuotes || x.TrimLeadingSpace || x.FieldsPerRecord != 0) {
+		return Xsv{}, fmt.Errorf("--csv-* options require --%s, --%s or --%s", NameCsv, NameTsv, NameCsvDelimiter)
+	}
+	if x.Comment != 0 && x.Comment == comma {
+		return Xsv{}, fmt.Errorf("--%s must be different from the delimiter", NameCsvComment)
+	}
+	return x, nil
+}
+
+// parseQuoteChars は --quote-chars をチェックする。クォートに使えるのは空白と \ 以外の ASCII 文字だけ
+func parseQuoteChars(s string) (string, error) {
+	if s == "" {
+		return DefaultQuoteChars, nil
+	}
+	for _, r := range s {
+		if r > unicode.MaxASCII || unicode.IsSpace(r) || r == '\\' {
+			return "", fmt.Errorf("--%s: %q can not be used as a quote", NameQuoteChars, r)
+		}
+	}
+	return s, nil
+}
+
+// IsLabeled は --ltsv や --logfmt のように、入力のカラムにラベルがついているかどうかを返す
+func (o Option) IsLabeled() bool {
+	return o.Ltsv || o.Logfmt
+}
+
+// HasColumnNames は --header や --ltsv, --jsonl のように、カラム名でカラムを選べる入力かどうかを返す
+func (o Option) HasColumnNames() bool {
+	return o.UseHeader || o.IsLabeled() || o.Jsonl
+}
+
+// FixedWidth is option group for fixed-width input
+type FixedWidth struct {
+	// --widths。行末までのカラムは -1
+	Widths []int
+	// --cuts。各カラムが始まる位置で、1-indexed
+	Cuts []int
+	// --display-width。位置を rune ではなく表示幅で数える
+	DisplayWidth bool
+	// --aligned。カラムの位置をヘッダーから推測する
+	Aligned bool
+	// --aligned-sample。カラムの位置を推測するときに見るヘッダー以降の行数
+	AlignedSample int
+}
+
+// IsFixedWidth は固定長の入力として扱うかどうかを返す
+func (f FixedWidth) IsFixedWidth() bool {
+	return len(f.Widths) != 0 || len(f.Cuts) != 0
+}
+
+// Boundaries は各カラムの開始位置と、最後のカラムの終了位置を返す。位置は 0-indexed で、end が負なら行末まで
+func (f FixedWidth) Boundaries() (starts []int, end int) {
+	if len(f.Cuts) != 0 {
+		for _, c := range f.Cuts {
+			starts = append(starts, c-1)
+		}
+		return starts, -1
+	}
+
+	pos := 0
+	for _, w := range f.Widths {
+		starts = append(starts, pos)
+		if w < 0 {
+			return starts, -1
+		}
+		pos += w
+	}
+	return starts, pos
+}
+
+// parseWidths は --widths の 5,10,3,- をパースする。- は行末までで、最後にしか書けない
+func parseWidths(values []string) ([]int, error) {
+	var widths []int
+	for i, v := range values {
+		if v == "-" {
+			if i != len(values)-1 {
+				return nil, fmt.Errorf("--%s: '-' must be the last width", NameWidths)
+			}
+			widths = append(widths, -1)
+			continue
+		}
+
+		w, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %s is not a number", NameWidths, v)
+		}
+		if w <= 0 {
+			return nil, fmt.Errorf("--%s: width must be bigger than 0", NameWidths)
+		}
+		widths = append(widths, w)
+	}
+	return widths, nil
+}
+
+// parseCuts は --cuts の 1,6,16 をパースする。位置は昇順でなければならない
+func parseCuts(values []string) ([]int, error) {
+	var cuts []int
+	for _, v := range values {
+		c, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %s is not a number", NameCuts, v)
+		}
+		if c < 1 || (len(cuts) != 0 && c <= cuts[len(cuts)-1]) {
+			return nil, fmt.Errorf("--%s: positions must be in ascending order and start from 1", NameCuts)
+		}
+		cuts = append(cuts, c)
+	}
+	return cuts, nil
+}
+
 // NewOption は viper.Viper からフラグの値を取り出して Option を作って返す
 func NewOption(v *viper.Viper) (Option, error) {
 
@@ -154,6 +715,69 @@ func NewOption(v *viper.Viper) (Option, error) {
 		}
 	}
 
+	widths, err := parseWidths(v.GetStringSlice(NameWidths))
+	if err != nil {
+		return Option{}, err
+	}
+	cuts, err := parseCuts(v.GetStringSlice(NameCuts))
+	if err != nil {
+		return Option{}, err
+	}
+
+	alignedSample := v.GetInt(NameAlignedSample)
+	if alignedSample < 0 {
+		return Option{}, fmt.Errorf("--%s must not be negative", NameAlignedSample)
+	}
+
+	xsv, err := parseXsv(v)
+	if err != nil {
+		return Option{}, err
+	}
+
+	record, err := parseRecord(v)
+	if err != nil {
+		return Option{}, err
+	}
+
+	inputEncoding, err := parseEncoding(NameInputEncoding, v.GetString(NameInputEncoding))
+	if err != nil {
+		return Option{}, err
+	}
+	outputEncoding, err := parseEncoding(NameOutputEncoding, v.GetString(NameOutputEncoding))
+	if err != nil {
+		return Option{}, err
+	}
+
+	outputFormat, comma, err := parseOutputFormat(v, xsv, tmpl != nil)
+	if err != nil {
+		return Option{}, err
+	}
+
+	// JSON や表はカラムの名前を自分で書き出すので、ヘッダー行を足すと二重になる
+	if (v.GetBool(NameKeepHeader) || v.GetBool(NameEmitHeader)) && (OutputOption{OutputFormat: outputFormat}).IsNamed() {
+		return Option{}, fmt.Errorf("--%s and --%s can not be used with --%s %s", NameKeepHeader, NameEmitHeader, NameOutputFormat, outputFormat)
+	}
+
+	quote := v.GetString(NameOutputQuote)
+	switch quote {
+	case "", QuoteMinimal, QuoteAll, QuoteNonNumeric:
+	default:
+		return Option{}, fmt.Errorf("--%s: %s is not supported (minimal, all, non-numeric)", NameOutputQuote, quote)
+	}
+
+	tableSample := v.GetInt(NameTableSample)
+	if tableSample < 0 {
+		return Option{}, fmt.Errorf("--%s must not be negative", NameTableSample)
+	}
+
+	var quoteChars string
+	if v.GetBool(NameQuoted) {
+		quoteChars, err = parseQuoteChars(v.GetString(NameQuoteChars))
+		if err != nil {
+			return Option{}, err
+		}
+	}
+
 	fillMissing := v.GetString(NameFillMissing)
 	ignoreMissing := v.GetBool(NameIgnoreMissing) || fillMissing != DefaultFillMissing
 
@@ -168,14 +792,94 @@ func NewOption(v *viper.Viper) (Option, error) {
 			FillMissing:     fillMissing,
 		},
 		InputFiles: InputFiles{v.GetStringSlice(NameInputFiles)},
-		Xsv: Xsv{
-			Csv: v.GetBool(NameCsv),
-			Tsv: v.GetBool(NameTsv),
+		Xsv:        xsv,
+		FixedWidth: FixedWidth{
+			Widths:        widths,
+			Cuts:          cuts,
+			DisplayWidth:  v.GetBool(NameDisplayWidth),
+			Aligned:       v.GetBool(NameAligned),
+			AlignedSample: alignedSample,
+		},
+		HeaderOption: HeaderOption{
+			// --aligned は最初の行をヘッダーとして使う
+			UseHeader:  v.GetBool(NameHeader) || v.GetBool(NameAligned),
+			KeepHeader: v.GetBool(NameKeepHeader),
+			EmitHeader: v.GetBool(NameEmitHeader),
+		},
+		RecordOption: record,
+		EncodingOption: EncodingOption{
+			InputEncoding:  inputEncoding,
+			OutputEncoding: outputEncoding,
+		},
+		DecompressOption: DecompressOption{
+			NoDecompress: v.GetBool(NameNoDecompress),
+		},
+		WhereOption: WhereOption{
+			Where:       v.GetStringSlice(NameWhere),
+			InvertWhere: v.GetBool(NameInvertWhere),
+		},
+		JsonlOption: JsonlOption{
+			Jsonl: v.GetBool(NameJsonl),
+		},
+		LtsvOption: LtsvOption{
+			Ltsv: v.GetBool(NameLtsv),
+		},
+		LogfmtOption: LogfmtOption{
+			Logfmt: v.GetBool(NameLogfmt),
+		},
+		QuotedOption: QuotedOption{
+			Quoted:     v.GetBool(NameQuoted),
+			QuoteChars: quoteChars,
+			NoEscape:   v.GetBool(NameNoEscape),
+		},
+		RowsOption: RowsOption{
+			Rows: v.GetString(NameRows),
+		},
+		OutputOption: OutputOption{
+			OutputFormat: outputFormat,
+			Comma:        comma,
+			InferTypes:   v.GetBool(NameInferTypes),
+			JSONObject:   v.GetBool(NameJSONObject),
+			Quote:        quote,
+			CRLF:         v.GetBool(NameCRLF),
+			TableSample:  tableSample,
 		},
 		Template: tmpl,
 	}, nil
 }
 
+// parseOutputFormat は --output-format, --output-csv, --output-tsv から出力の形式を決める
+// 入力が CSV/TSV/LTSV で、-D も --template も出力の形式も指定されていなければ、入力と同じ形式で出力する
+// --csv-delimiter を指定した入力と同じ形式で出力するときは、その区切り文字も返す
+func parseOutputFormat(v *viper.Viper, xsv Xsv, hasTemplate bool) (string, rune, error) {
+	format := v.GetString(NameOutputFormat)
+	switch format {
+	case "", FormatJSON, FormatJSONL, FormatCSV, FormatTSV, FormatTable, FormatMarkdown, FormatGithub, FormatLTSV, FormatLogfmt:
+	default:
+		return "", 0, fmt.Errorf("--%s: %s is not supported (json, jsonl, csv, tsv, table, markdown, github, ltsv, logfmt)", NameOutputFormat, format)
+	}
+
+	if v.GetBool(NameOutputCsv) {
+		format = FormatCSV
+	} else if v.GetBool(NameOutputTsv) {
+		format = FormatTSV
+	}
+
+	if len(format) != 0 || hasTemplate || v.IsSet(NameOutPutDelimiter) {
+		return format, 0, nil
+	}
+	if xsv.Delimiter != 0 {
+		return FormatCSV, xsv.Delimiter, nil
+	} else if xsv.Csv {
+		return FormatCSV, 0, nil
+	} else if xsv.Tsv {
+		return FormatTSV, 0, nil
+	} else if v.GetBool(NameLtsv) {
+		return FormatLTSV, 0, nil
+	}
+	return "", 0, nil
+}
+
 func parseTemplate(input string) (*template.Template, error) {
 	var result string
 
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
 	"testing"
 
@@ -12,6 +13,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/xztaityozx/sel/internal/option"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
 )
 
 func TestInputFiles_Enumerate(t *testing.T) {
@@ -84,6 +88,20 @@ func TestInputFiles_Enumerate(t *testing.T) {
 		}
 	})
 
+	t.Run("アーカイブの中のファイルのパターンはそのまま残す", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, name := range []string{"a.zip", "b.tar.gz"} {
+			_ = os.WriteFile(filepath.Join(dir, name), nil, 0644)
+		}
+
+		a, err := option.InputFiles{Files: []string{filepath.Join(dir, "*.zip") + ":reports/*.csv", filepath.Join(dir, "b.tar.gz")}}.Enumerate()
+		as.Nil(err)
+		as.Equal([]string{filepath.Join(dir, "a.zip") + ":reports/*.csv", filepath.Join(dir, "b.tar.gz")}, a)
+
+		_, err = option.InputFiles{Files: []string{filepath.Join(dir, "c.zip") + ":*.csv"}}.Enumerate()
+		as.Error(err)
+	})
+
 	_ = os.RemoveAll(base)
 }
 
@@ -105,6 +123,45 @@ func TestGetOptionNames(t *testing.T) {
 			option.NameCsv,
 			option.NameTsv,
 			option.NameTemplate,
+			option.NameHeader,
+			option.NameWhere,
+			option.NameInvertWhere,
+			option.NameRows,
+			option.NameWidths,
+			option.NameCuts,
+			option.NameDisplayWidth,
+			option.NameAligned,
+			option.NameAlignedSample,
+			option.NameJsonl,
+			option.NameOutputFormat,
+			option.NameInferTypes,
+			option.NameJSONObject,
+			option.NameOutputCsv,
+			option.NameOutputTsv,
+			option.NameOutputQuote,
+			option.NameCRLF,
+			option.NameTableSample,
+			option.NameLtsv,
+			option.NameLogfmt,
+			option.NameQuoted,
+			option.NameQuoteChars,
+			option.NameNoEscape,
+			option.NameCsvDelimiter,
+			option.NameCsvComment,
+			option.NameCsvLazyQuotes,
+			option.NameCsvTrimSpace,
+			option.NameCsvFields,
+			option.NameKeepHeader,
+			option.NameEmitHeader,
+			option.NameNullData,
+			option.NameRecordSep,
+			option.NameOutputRecordSep,
+			option.NameRecordStart,
+			option.NameContinuation,
+			option.NameRecordJoin,
+			option.NameInputEncoding,
+			option.NameOutputEncoding,
+			option.NameNoDecompress,
 		}},
 	}
 	for _, tt := range tests {
@@ -164,8 +221,9 @@ func TestNewOption(t *testing.T) {
 func TestXsv_IsXsv(t *testing.T) {
 	as := assert.New(t)
 	type fields struct {
-		csv bool
-		tsv bool
+		csv       bool
+		tsv       bool
+		delimiter rune
 	}
 
 	type wants struct {
@@ -178,17 +236,301 @@ func TestXsv_IsXsv(t *testing.T) {
 		fields fields
 		wants  wants
 	}{
-		{"CSV", fields{true, false}, wants{true, ','}},
-		{"TSV", fields{false, true}, wants{true, '\t'}},
-		{"CSV && TSV to be CSV", fields{true, true}, wants{true, ','}},
-		{"not XSV", fields{false, false}, wants{false, ','}},
+		{"CSV", fields{true, false, 0}, wants{true, ','}},
+		{"TSV", fields{false, true, 0}, wants{true, '\t'}},
+		{"CSV && TSV to be CSV", fields{true, true, 0}, wants{true, ','}},
+		{"not XSV", fields{false, false, 0}, wants{false, ','}},
+		{"delimiter", fields{false, false, ';'}, wants{true, ';'}},
+		{"CSV with delimiter", fields{true, false, '|'}, wants{true, '|'}},
+		{"multibyte delimiter", fields{false, false, '、'}, wants{true, '、'}},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotOk, gotComma := option.Xsv{Csv: tt.fields.csv, Tsv: tt.fields.tsv}.IsXsv()
+			gotOk, gotComma := option.Xsv{Csv: tt.fields.csv, Tsv: tt.fields.tsv, Delimiter: tt.fields.delimiter}.IsXsv()
 			as.Equal(tt.wants.ok, gotOk)
 			as.Equal(tt.wants.comma, gotComma)
 		})
 	}
 }
+
+func TestNewOption_Xsv(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     map[string]interface{}
+		want    option.Xsv
+		wantErr bool
+	}{
+		{name: "--csv-delimiter", set: map[string]interface{}{option.NameCsvDelimiter: ";"}, want: option.Xsv{Delimiter: ';'}},
+		{name: "--csv-delimiter \\t", set: map[string]interface{}{option.NameCsvDelimiter: `\t`}, want: option.Xsv{Delimiter: '\t'}},
+		{
+			name: "all --csv-* options",
+			set: map[string]interface{}{
+				option.NameCsv: true, option.NameCsvComment: "#", option.NameCsvLazyQuotes: true,
+				option.NameCsvTrimSpace: true, option.NameCsvFields: -1,
+			},
+			want: option.Xsv{Csv: true, Comment: '#', LazyQuotes: true, TrimLeadingSpace: true, FieldsPerRecord: -1},
+		},
+		{name: "multi characters", set: map[string]interface{}{option.NameCsvDelimiter: ";;"}, wantErr: true},
+		{name: "quote as delimiter", set: map[string]interface{}{option.NameCsvDelimiter: `"`}, wantErr: true},
+		{name: "comment same as delimiter", set: map[string]interface{}{option.NameTsv: true, option.NameCsvComment: "\t"}, wantErr: true},
+		{name: "without CSV input", set: map[string]interface{}{option.NameCsvLazyQuotes: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			for k, val := range tt.set {
+				v.Set(k, val)
+			}
+			got, err := option.NewOption(v)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.Xsv)
+		})
+	}
+}
+
+func TestNewOption_Record(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     map[string]interface{}
+		want    option.RecordOption
+		wantErr bool
+	}{
+		{name: "-z", set: map[string]interface{}{option.NameNullData: true}, want: option.RecordOption{RecordSeparator: "\x00", OutputRecordSeparator: "\x00"}},
+		{name: "-z --output-record-separator", set: map[string]interface{}{option.NameNullData: true, option.NameOutputRecordSep: `\n`}, want: option.RecordOption{RecordSeparator: "\x00", OutputRecordSeparator: "\n"}},
+		{name: "literal", set: map[string]interface{}{option.NameRecordSep: `;\t`}, want: option.RecordOption{RecordSeparator: ";\t"}},
+		{name: "paragraph", set: map[string]interface{}{option.NameRecordSep: ""}, want: option.RecordOption{Paragraph: true}},
+		{name: "regexp", set: map[string]interface{}{option.NameRecordSep: `/\n-+\n/`}, want: option.RecordOption{RecordRegexp: regexp.MustCompile(`\n-+\n`)}},
+		{name: "regexp matches empty", set: map[string]interface{}{option.NameRecordSep: `/x*/`}, wantErr: true},
+		{name: "invalid regexp", set: map[string]interface{}{option.NameRecordSep: `/(/`}, wantErr: true},
+		{name: "with --csv", set: map[string]interface{}{option.NameCsv: true, option.NameNullData: true}, wantErr: true},
+		{name: "--record-start", set: map[string]interface{}{option.NameRecordStart: `/^\d{4}-/`}, want: option.RecordOption{RecordStart: regexp.MustCompile(`^\d{4}-`), RecordJoin: "\n"}},
+		{name: "--continuation --record-join", set: map[string]interface{}{option.NameContinuation: `^\s+`, option.NameRecordJoin: `\t`}, want: option.RecordOption{Continuation: regexp.MustCompile(`^\s+`), RecordJoin: "\t"}},
+		{name: "--record-join only", set: map[string]interface{}{option.NameRecordJoin: " "}, want: option.RecordOption{}},
+		{name: "invalid --record-start", set: map[string]interface{}{option.NameRecordStart: `(`}, wantErr: true},
+		{name: "--continuation with --tsv", set: map[string]interface{}{option.NameTsv: true, option.NameContinuation: `^\s`}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			for k, val := range tt.set {
+				v.Set(k, val)
+			}
+			got, err := option.NewOption(v)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.RecordOption)
+		})
+	}
+
+	assert.Equal(t, "\n", option.RecordOption{}.OutputLineEnd())
+}
+
+func TestNewOption_Encoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    encoding.Encoding
+		wantErr bool
+	}{
+		{name: "empty", value: "", want: nil},
+		{name: "UTF-8", value: "UTF-8", want: nil},
+		{name: "Shift_JIS", value: "Shift_JIS", want: japanese.ShiftJIS},
+		{name: "cp932", value: "CP932", want: japanese.ShiftJIS},
+		{name: "EUC-JP", value: "euc_jp", want: japanese.EUCJP},
+		{name: "ISO-2022-JP", value: "iso-2022-jp", want: japanese.ISO2022JP},
+		{name: "Latin-1", value: "latin1", want: charmap.ISO8859_1},
+		{name: "unknown", value: "ebcdic", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			v.Set(option.NameInputEncoding, tt.value)
+			v.Set(option.NameOutputEncoding, tt.value)
+			got, err := option.NewOption(v)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, option.EncodingOption{InputEncoding: tt.want, OutputEncoding: tt.want}, got.EncodingOption)
+		})
+	}
+
+	v := viper.New()
+	v.Set(option.NameInputEncoding, "utf-16le")
+	got, err := option.NewOption(v)
+	assert.NoError(t, err)
+	assert.NotNil(t, got.InputEncoding)
+}
+
+func TestNewOption_FixedWidth(t *testing.T) {
+	tests := []struct {
+		name    string
+		widths  []string
+		cuts    []string
+		want    option.FixedWidth
+		wantErr bool
+	}{
+		{name: "widths", widths: []string{"5", "10", "3", "-"}, want: option.FixedWidth{Widths: []int{5, 10, 3, -1}}},
+		{name: "cuts", cuts: []string{"1", "6", "16"}, want: option.FixedWidth{Cuts: []int{1, 6, 16}}},
+		{name: "- is not last", widths: []string{"5", "-", "3"}, wantErr: true},
+		{name: "zero width", widths: []string{"5", "0"}, wantErr: true},
+		{name: "not number", widths: []string{"a"}, wantErr: true},
+		{name: "cuts not ascending", cuts: []string{"6", "1"}, wantErr: true},
+		{name: "cuts from 0", cuts: []string{"0", "5"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			v.Set(option.NameWidths, tt.widths)
+			v.Set(option.NameCuts, tt.cuts)
+
+			got, err := option.NewOption(v)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.FixedWidth)
+			assert.True(t, got.IsFixedWidth())
+		})
+	}
+}
+
+func TestNewOption_Aligned(t *testing.T) {
+	v := viper.New()
+	v.Set(option.NameAligned, true)
+	v.Set(option.NameAlignedSample, 10)
+
+	got, err := option.NewOption(v)
+	assert.NoError(t, err)
+	assert.Equal(t, option.FixedWidth{Aligned: true, AlignedSample: 10}, got.FixedWidth)
+	assert.True(t, got.UseHeader, "--aligned ならヘッダーを使うべき")
+	assert.False(t, got.IsFixedWidth())
+
+	v.Set(option.NameAlignedSample, -1)
+	_, err = option.NewOption(v)
+	assert.Error(t, err)
+}
+
+func TestNewOption_Quoted(t *testing.T) {
+	v := viper.New()
+	v.Set(option.NameQuoted, true)
+
+	got, err := option.NewOption(v)
+	assert.NoError(t, err)
+	assert.Equal(t, option.QuotedOption{Quoted: true, QuoteChars: option.DefaultQuoteChars}, got.QuotedOption)
+
+	v.Set(option.NameQuoteChars, "`")
+	v.Set(option.NameNoEscape, true)
+	got, err = option.NewOption(v)
+	assert.NoError(t, err)
+	assert.Equal(t, option.QuotedOption{Quoted: true, QuoteChars: "`", NoEscape: true}, got.QuotedOption)
+
+	for _, chars := range []string{" ", `\`, "「」"} {
+		v.Set(option.NameQuoteChars, chars)
+		_, err = option.NewOption(v)
+		assert.Error(t, err, chars)
+	}
+}
+
+func TestNewOption_OutputFormat(t *testing.T) {
+	for _, format := range []string{"", "json", "jsonl"} {
+		t.Run(format, func(t *testing.T) {
+			v := viper.New()
+			v.Set(option.NameOutputFormat, format)
+			v.Set(option.NameInferTypes, true)
+
+			got, err := option.NewOption(v)
+			assert.NoError(t, err)
+			assert.Equal(t, option.OutputOption{OutputFormat: format, InferTypes: true}, got.OutputOption)
+			assert.Equal(t, format != "", got.IsJSON())
+		})
+	}
+
+	v := viper.New()
+	v.Set(option.NameOutputFormat, "yaml")
+	_, err := option.NewOption(v)
+	assert.Error(t, err)
+}
+
+func TestNewOption_OutputXsv(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     map[string]interface{}
+		want    option.OutputOption
+		wantErr bool
+	}{
+		{name: "--output-csv", set: map[string]interface{}{option.NameOutputCsv: true, option.NameOutputQuote: "all", option.NameCRLF: true}, want: option.OutputOption{OutputFormat: option.FormatCSV, Quote: option.QuoteAll, CRLF: true}},
+		{name: "--output-tsv", set: map[string]interface{}{option.NameOutputTsv: true}, want: option.OutputOption{OutputFormat: option.FormatTSV}},
+		{name: "--output-format csv", set: map[string]interface{}{option.NameOutputFormat: "csv"}, want: option.OutputOption{OutputFormat: option.FormatCSV}},
+		{name: "--csv", set: map[string]interface{}{option.NameCsv: true}, want: option.OutputOption{OutputFormat: option.FormatCSV}},
+		{name: "--tsv", set: map[string]interface{}{option.NameTsv: true, option.NameOutputQuote: "non-numeric"}, want: option.OutputOption{OutputFormat: option.FormatTSV, Quote: option.QuoteNonNumeric}},
+		{name: "--csv-delimiter", set: map[string]interface{}{option.NameCsvDelimiter: ";"}, want: option.OutputOption{OutputFormat: option.FormatCSV, Comma: ';'}},
+		{name: "--csv-delimiter --output-csv", set: map[string]interface{}{option.NameCsvDelimiter: ";", option.NameOutputCsv: true}, want: option.OutputOption{OutputFormat: option.FormatCSV}},
+		{name: "--csv -D", set: map[string]interface{}{option.NameCsv: true, option.NameOutPutDelimiter: " "}, want: option.OutputOption{}},
+		{name: "--csv --template", set: map[string]interface{}{option.NameCsv: true, option.NameTemplate: "{}"}, want: option.OutputOption{}},
+		{name: "--csv --output-format jsonl", set: map[string]interface{}{option.NameCsv: true, option.NameOutputFormat: "jsonl"}, want: option.OutputOption{OutputFormat: option.FormatJSONL}},
+		{name: "--output-format markdown", set: map[string]interface{}{option.NameOutputFormat: "markdown", option.NameTableSample: 10}, want: option.OutputOption{OutputFormat: option.FormatMarkdown, TableSample: 10}},
+		{name: "--table-sample negative", set: map[string]interface{}{option.NameOutputFormat: "table", option.NameTableSample: -1}, wantErr: true},
+		{name: "--ltsv", set: map[string]interface{}{option.NameLtsv: true}, want: option.OutputOption{OutputFormat: option.FormatLTSV}},
+		{name: "--ltsv -D", set: map[string]interface{}{option.NameLtsv: true, option.NameOutPutDelimiter: ","}, want: option.OutputOption{}},
+		{name: "--logfmt", set: map[string]interface{}{option.NameLogfmt: true}, want: option.OutputOption{}},
+		{name: "--output-format logfmt", set: map[string]interface{}{option.NameLogfmt: true, option.NameOutputFormat: "logfmt"}, want: option.OutputOption{OutputFormat: option.FormatLogfmt}},
+		{name: "--emit-header --output-format csv", set: map[string]interface{}{option.NameEmitHeader: true, option.NameOutputFormat: "csv"}, want: option.OutputOption{OutputFormat: option.FormatCSV}},
+		{name: "--keep-header --output-format json", set: map[string]interface{}{option.NameKeepHeader: true, option.NameOutputFormat: "json"}, wantErr: true},
+		{name: "--emit-header --ltsv", set: map[string]interface{}{option.NameEmitHeader: true, option.NameLtsv: true}, wantErr: true},
+		{name: "--output-quote unknown", set: map[string]interface{}{option.NameOutputCsv: true, option.NameOutputQuote: "none"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			for k, val := range tt.set {
+				v.Set(k, val)
+			}
+
+			got, err := option.NewOption(v)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.OutputOption)
+		})
+	}
+}
+
+func TestFixedWidth_Boundaries(t *testing.T) {
+	tests := []struct {
+		name       string
+		fixed      option.FixedWidth
+		wantStarts []int
+		wantEnd    int
+	}{
+		{name: "5,10,3,-", fixed: option.FixedWidth{Widths: []int{5, 10, 3, -1}}, wantStarts: []int{0, 5, 15, 18}, wantEnd: -1},
+		{name: "5,10,3", fixed: option.FixedWidth{Widths: []int{5, 10, 3}}, wantStarts: []int{0, 5, 15}, wantEnd: 18},
+		{name: "cuts 1,6,16", fixed: option.FixedWidth{Cuts: []int{1, 6, 16}}, wantStarts: []int{0, 5, 15}, wantEnd: -1},
+		{name: "cuts 3,6", fixed: option.FixedWidth{Cuts: []int{3, 6}}, wantStarts: []int{2, 5}, wantEnd: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			starts, end := tt.fixed.Boundaries()
+			assert.Equal(t, tt.wantStarts, starts)
+			assert.Equal(t, tt.wantEnd, end)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+
+	"github.com/xztaityozx/sel/internal/option"
+)
+
+// csvFormatter は RFC 4180 に従って1行を CSV/TSV のレコードにして書き出すやつ
+type csvFormatter struct {
+	comma rune
+	quote string
+	// lineEnd はレコードの終わりに書き込む改行
+	lineEnd string
+}
+
+// lineEnd は --crlf でなければレコードの終わりに書き込む文字列
+func newCsvFormatter(comma rune, opt option.OutputOption, lineEnd string) *csvFormatter {
+	c := &csvFormatter{comma: comma, quote: opt.Quote, lineEnd: lineEnd}
+	if opt.CRLF {
+		c.lineEnd = "\r\n"
+	}
+	return c
+}
+
+var numericValidator = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+
+// needsQuote は field をクォートするかどうかを返す
+// クォートしないと壊れるカラムは --output-quote によらずクォートする。
+// カラムが1つだけで空のときも、空行と区別できるようにクォートする
+func (c *csvFormatter) needsQuote(field string, columns int) bool {
+	if strings.ContainsRune(field, c.comma) || strings.ContainsAny(field, "\"\r\n") {
+		return true
+	}
+	switch c.quote {
+	case option.QuoteAll:
+		return true
+	case option.QuoteNonNumeric:
+		return !numericValidator.MatchString(field)
+	}
+	return len(field) == 0 && columns == 1
+}
+
+func (c *csvFormatter) row(buf *bufio.Writer, _, columns []string) error {
+	for i, field := range columns {
+		if i != 0 {
+			if _, err := buf.WriteRune(c.comma); err != nil {
+				return err
+			}
+		}
+
+		if !c.needsQuote(field, len(columns)) {
+			if _, err := buf.WriteString(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := buf.WriteByte('"'); err != nil {
+			return err
+		}
+		if _, err := buf.WriteString(strings.ReplaceAll(field, `"`, `""`)); err != nil {
+			return err
+		}
+		if err := buf.WriteByte('"'); err != nil {
+			return err
+		}
+	}
+
+	_, err := buf.WriteString(c.lineEnd)
+	return err
+}
+
+func (c *csvFormatter) close(_ *bufio.Writer) error {
+	return nil
+}
@@ -0,0 +1,57 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/option"
+)
+
+func TestWriter_Csv(t *testing.T) {
+	rows := [][]string{
+		{"1", "a,b", `say "hi"`, "multi\nline", ""},
+		{"-2.5e3", "1.", " x", "007", "a\tb"},
+		{""},
+	}
+
+	tests := []struct {
+		name   string
+		option option.OutputOption
+		want   string
+	}{
+		{
+			name:   "csv minimal",
+			option: option.OutputOption{OutputFormat: option.FormatCSV},
+			want:   "1,\"a,b\",\"say \"\"hi\"\"\",\"multi\nline\",\n-2.5e3,1., x,007,a\tb\n\"\"\n",
+		},
+		{
+			name:   "csv all with CRLF",
+			option: option.OutputOption{OutputFormat: option.FormatCSV, Quote: option.QuoteAll, CRLF: true},
+			want:   "\"1\",\"a,b\",\"say \"\"hi\"\"\",\"multi\nline\",\"\"\r\n\"-2.5e3\",\"1.\",\" x\",\"007\",\"a\tb\"\r\n\"\"\r\n",
+		},
+		{
+			name:   "csv non-numeric",
+			option: option.OutputOption{OutputFormat: option.FormatCSV, Quote: option.QuoteNonNumeric},
+			want:   "1,\"a,b\",\"say \"\"hi\"\"\",\"multi\nline\",\"\"\n-2.5e3,1.,\" x\",007,\"a\tb\"\n\"\"\n",
+		},
+		{
+			name:   "tsv minimal",
+			option: option.OutputOption{OutputFormat: option.FormatTSV},
+			want:   "1\ta,b\t\"say \"\"hi\"\"\"\t\"multi\nline\"\t\n-2.5e3\t1.\t x\t007\t\"a\tb\"\n\"\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewWriter(option.Option{OutputOption: tt.option}, buf, false)
+			for _, r := range rows {
+				assert.NoError(t, w.Write(r...))
+				assert.NoError(t, w.WriteNewLine())
+			}
+			assert.NoError(t, w.Close())
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package output
+
+import (
+	"bufio"
+	"strconv"
+
+	"github.com/xztaityozx/sel/internal/jsonl"
+	"github.com/xztaityozx/sel/internal/option"
+)
+
+// formatter は --output-format で選んだ形式で1行分のカラムを書き出すやつ
+type formatter interface {
+	// row は1行分のカラムを書き出す。names は columns のそれぞれにつけられた名前
+	row(buf *bufio.Writer, names, columns []string) error
+	// close はすべての行を書き出した後に呼ばれる
+	close(buf *bufio.Writer) error
+}
+
+// newFormatter は --output-format に対応する formatter を返す。指定がなければ nil
+func newFormatter(opt option.Option) formatter {
+	if opt.IsJSON() {
+		return &jsonFormatter{
+			array:      opt.OutputFormat == option.FormatJSON,
+			object:     opt.HasColumnNames() || opt.JSONObject,
+			inferTypes: opt.InferTypes,
+			lineEnd:    []byte(opt.OutputLineEnd()),
+		}
+	}
+	if ok, comma := opt.IsXsvOutput(); ok {
+		return newCsvFormatter(comma, opt.OutputOption, opt.OutputLineEnd())
+	}
+	if opt.IsTable() {
+		return newTableFormatter(opt)
+	}
+	switch opt.OutputFormat {
+	case option.FormatLTSV:
+		return ltsvFormatter{lineEnd: []byte(opt.OutputLineEnd())}
+	case option.FormatLogfmt:
+		return logfmtFormatter{lineEnd: []byte(opt.OutputLineEnd())}
+	}
+	return nil
+}
+
+// jsonFormatter は1行を1つの JSON の配列かオブジェクトにして書き出すやつ
+type jsonFormatter struct {
+	// array なら全体を1つの配列にする(--output-format json)。そうでなければ1行に1つずつ書き出す(--output-format jsonl)
+	array bool
+	// object ならカラムの名前をキーにしたオブジェクトにする
+	object     bool
+	inferTypes bool
+	rows       int
+	// lineEnd は --output-format jsonl で1行ごとに書き込むレコードの区切り
+	lineEnd []byte
+}
+
+func (j *jsonFormatter) row(buf *bufio.Writer, names, columns []string) error {
+	b, err := j.value(names, columns).MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	if j.array {
+		if j.rows == 0 {
+			_, err = buf.WriteString("[\n  ")
+		} else {
+			_, err = buf.WriteString(",\n  ")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	j.rows++
+
+	if _, err := buf.Write(b); err != nil {
+		return err
+	}
+	if !j.array {
+		_, err = buf.Write(j.lineEnd)
+	}
+	return err
+}
+
+func (j *jsonFormatter) close(buf *bufio.Writer) error {
+	if !j.array {
+		return nil
+	}
+	if j.rows == 0 {
+		_, err := buf.WriteString("[]\n")
+		return err
+	}
+	_, err := buf.WriteString("\n]\n")
+	return err
+}
+
+// value は1行分のカラムを JSON の値にする
+// オブジェクトにするとき、同じ名前のカラムが複数あればその名前の値は配列にする。名前がないカラムは何番目かをキーにする
+func (j *jsonFormatter) value(names, columns []string) jsonl.Value {
+	values := make([]jsonl.Value, 0, len(columns))
+	for _, c := range columns {
+		if j.inferTypes {
+			values = append(values, jsonl.Infer(c))
+		} else {
+			values = append(values, jsonl.NewString(c))
+		}
+	}
+
+	if !j.object {
+		return jsonl.NewArray(values...)
+	}
+
+	var keys []string
+	groups := map[string][]jsonl.Value{}
+	for i, v := range values {
+		key := names[i]
+		if len(key) == 0 {
+			key = strconv.Itoa(i + 1)
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], v)
+	}
+
+	fields := make([]jsonl.Value, 0, len(keys))
+	for _, key := range keys {
+		if g := groups[key]; len(g) == 1 {
+			fields = append(fields, g[0])
+		} else {
+			fields = append(fields, jsonl.NewArray(g...))
+		}
+	}
+	return jsonl.NewObject(keys, fields)
+}
@@ -0,0 +1,101 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/option"
+)
+
+func TestWriter_JSON(t *testing.T) {
+	type row struct {
+		names   []string
+		columns []string
+	}
+	rows := []row{
+		{names: []string{"id", "name", "tag", "tag"}, columns: []string{"1", "a\"<b>", "x", "y"}},
+		{names: []string{"id", "", "tag"}, columns: []string{"2", "", "true"}},
+	}
+
+	tests := []struct {
+		name   string
+		option option.OutputOption
+		header bool
+		jsonl  bool
+		want   string
+	}{
+		{
+			name:   "jsonl array",
+			option: option.OutputOption{OutputFormat: option.FormatJSONL},
+			want:   "[\"1\",\"a\\\"<b>\",\"x\",\"y\"]\n[\"2\",\"\",\"true\"]\n",
+		},
+		{
+			name:   "json array with types",
+			option: option.OutputOption{OutputFormat: option.FormatJSON, InferTypes: true},
+			want:   "[\n  [1,\"a\\\"<b>\",\"x\",\"y\"],\n  [2,null,true]\n]\n",
+		},
+		{
+			name:   "jsonl object by header",
+			option: option.OutputOption{OutputFormat: option.FormatJSONL},
+			header: true,
+			want:   "{\"id\":\"1\",\"name\":\"a\\\"<b>\",\"tag\":[\"x\",\"y\"]}\n{\"id\":\"2\",\"2\":\"\",\"tag\":\"true\"}\n",
+		},
+		{
+			name:   "json object by --jsonl input",
+			option: option.OutputOption{OutputFormat: option.FormatJSON},
+			jsonl:  true,
+			want:   "[\n  {\"id\":\"1\",\"name\":\"a\\\"<b>\",\"tag\":[\"x\",\"y\"]},\n  {\"id\":\"2\",\"2\":\"\",\"tag\":\"true\"}\n]\n",
+		},
+		{
+			name:   "jsonl object by --json-object",
+			option: option.OutputOption{OutputFormat: option.FormatJSONL, JSONObject: true, InferTypes: true},
+			want:   "{\"id\":1,\"name\":\"a\\\"<b>\",\"tag\":[\"x\",\"y\"]}\n{\"id\":2,\"2\":null,\"tag\":true}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewWriter(option.Option{
+				OutputOption: tt.option,
+				HeaderOption: option.HeaderOption{UseHeader: tt.header},
+				JsonlOption:  option.JsonlOption{Jsonl: tt.jsonl},
+			}, buf, false)
+			for _, r := range rows {
+				for i, c := range r.columns {
+					assert.NoError(t, w.WriteNamed(r.names[i], c))
+				}
+				assert.NoError(t, w.WriteNewLine())
+			}
+			assert.NoError(t, w.Close())
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWriter_JSON_Empty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(option.Option{OutputOption: option.OutputOption{OutputFormat: option.FormatJSON}}, buf, false)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "[]\n", buf.String())
+
+	buf.Reset()
+	w = NewWriter(option.Option{OutputOption: option.OutputOption{OutputFormat: option.FormatJSONL}}, buf, false)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "", buf.String())
+}
+
+func TestWriter_JSON_Capture(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(option.Option{OutputOption: option.OutputOption{OutputFormat: option.FormatJSONL}}, buf, false)
+
+	w.Capture()
+	assert.NoError(t, w.WriteNamed("a", "b"))
+	assert.Equal(t, []string{"b"}, w.Release())
+
+	assert.NoError(t, w.Write("c"))
+	assert.NoError(t, w.WriteNewLine())
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "[\"c\"]\n", buf.String())
+}
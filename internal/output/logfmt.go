@@ -0,0 +1,59 @@
+package output
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// logfmtFormatter は1行をカラムの名前をキーにした logfmt にして書き出すやつ
+type logfmtFormatter struct {
+	lineEnd []byte
+}
+
+func (l logfmtFormatter) row(buf *bufio.Writer, names, columns []string) error {
+	for i, c := range columns {
+		if i != 0 {
+			if err := buf.WriteByte(' '); err != nil {
+				return err
+			}
+		}
+
+		// 名前がないカラムは何番目かをキーにする
+		key := logfmtKey(names[i])
+		if len(key) == 0 {
+			key = strconv.Itoa(i + 1)
+		}
+		if _, err := buf.WriteString(key + "=" + logfmtValue(c)); err != nil {
+			return err
+		}
+	}
+
+	_, err := buf.Write(l.lineEnd)
+	return err
+}
+
+func (logfmtFormatter) close(_ *bufio.Writer) error {
+	return nil
+}
+
+// logfmtKey はキーに使えない空白や = や " を _ にする
+func logfmtKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// logfmtValue は空白や = や " を含む値をクォートする
+func logfmtValue(s string) string {
+	if strings.IndexFunc(s, func(r rune) bool {
+		return r == '=' || r == '"' || r == '\\' || unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) < 0 {
+		return s
+	}
+	return strconv.Quote(s)
+}
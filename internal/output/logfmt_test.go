@@ -0,0 +1,25 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/option"
+)
+
+func TestWriter_Logfmt(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(option.Option{OutputOption: option.OutputOption{OutputFormat: option.FormatLogfmt}}, buf, false)
+
+	assert.NoError(t, w.WriteNamed("level", "info"))
+	assert.NoError(t, w.WriteNamed("msg", `hello "world"`))
+	assert.NoError(t, w.WriteNamed("=( $1 )", "a=b"))
+	assert.NoError(t, w.Write(""))
+	assert.NoError(t, w.WriteNewLine())
+	assert.NoError(t, w.WriteNamed("err", "x\ny"))
+	assert.NoError(t, w.WriteNewLine())
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, "level=info msg=\"hello \\\"world\\\"\" _(_$1_)=\"a=b\" 4=\nerr=\"x\\ny\"\n", buf.String())
+}
@@ -0,0 +1,41 @@
+package output
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// ltsvFormatter は1行をカラムの名前をラベルにした LTSV にして書き出すやつ
+type ltsvFormatter struct {
+	lineEnd []byte
+}
+
+// ltsvEscaper は値の中のタブや改行で LTSV が壊れないように書き換える
+var ltsvEscaper = strings.NewReplacer("\t", `\t`, "\r", `\r`, "\n", `\n`)
+
+func (l ltsvFormatter) row(buf *bufio.Writer, names, columns []string) error {
+	for i, c := range columns {
+		if i != 0 {
+			if err := buf.WriteByte('\t'); err != nil {
+				return err
+			}
+		}
+
+		// 名前がないカラムは何番目かをラベルにする
+		label := names[i]
+		if len(label) == 0 {
+			label = strconv.Itoa(i + 1)
+		}
+		if _, err := buf.WriteString(ltsvEscaper.Replace(label) + ":" + ltsvEscaper.Replace(c)); err != nil {
+			return err
+		}
+	}
+
+	_, err := buf.Write(l.lineEnd)
+	return err
+}
+
+func (ltsvFormatter) close(_ *bufio.Writer) error {
+	return nil
+}
@@ -0,0 +1,24 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/option"
+)
+
+func TestWriter_LTSV(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(option.Option{OutputOption: option.OutputOption{OutputFormat: option.FormatLTSV}}, buf, false)
+
+	assert.NoError(t, w.WriteNamed("host", "127.0.0.1"))
+	assert.NoError(t, w.WriteNamed("req", "GET /\tx\n"))
+	assert.NoError(t, w.Write("unnamed"))
+	assert.NoError(t, w.WriteNewLine())
+	assert.NoError(t, w.WriteNamed("status", "200"))
+	assert.NoError(t, w.WriteNewLine())
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, "host:127.0.0.1\treq:GET /\\tx\\n\t3:unnamed\nstatus:200\n", buf.String())
+}
@@ -0,0 +1,225 @@
+package output
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/xztaityozx/sel/internal/option"
+	"github.com/xztaityozx/sel/internal/width"
+)
+
+// tableFormatter は行を溜めておいて、カラムの幅を揃えた表にして書き出すやつ
+// 幅は表示幅で数えるので、全角文字が混ざっていても揃う
+type tableFormatter struct {
+	style string
+	// sample 行を読んだらカラムの幅を決めて書き出し始める。0 ならすべての行を読んでから
+	sample    int
+	useHeader bool
+	// header はヘッダー行。useHeader のときに最初の行のカラムの名前から作る
+	// カラムの名前は --header のときはヘッダーのカラム名で、そうでなければ選んだクエリ
+	header []string
+	rows   [][]string
+	// widths, numeric はカラムの幅と、数値だけのカラムかどうか。fixed になったら変えない
+	widths  []int
+	numeric []bool
+	fixed   bool
+}
+
+func newTableFormatter(opt option.Option) *tableFormatter {
+	// --ltsv や --logfmt のときはラベルを、--jsonl のときはキーをヘッダー行にする
+	// Markdown の表にはヘッダー行が必要なので、--header がなくてもクエリをカラムの名前にしてヘッダー行を作る
+	useHeader := opt.HasColumnNames() || opt.OutputFormat != option.FormatTable
+	return &tableFormatter{style: opt.OutputFormat, sample: opt.TableSample, useHeader: useHeader}
+}
+
+func (t *tableFormatter) row(buf *bufio.Writer, names, columns []string) error {
+	if t.useHeader && t.header == nil {
+		t.header = make([]string, 0, len(names))
+		for _, name := range names {
+			t.header = append(t.header, t.escape(name))
+		}
+	}
+
+	cells := make([]string, 0, len(columns))
+	for _, c := range columns {
+		cells = append(cells, t.escape(c))
+	}
+
+	if t.fixed {
+		return t.writeRow(buf, cells)
+	}
+
+	t.rows = append(t.rows, cells)
+	if t.sample != 0 && len(t.rows) >= t.sample {
+		return t.writeBuffered(buf)
+	}
+	return nil
+}
+
+func (t *tableFormatter) close(buf *bufio.Writer) error {
+	if !t.fixed {
+		if err := t.writeBuffered(buf); err != nil {
+			return err
+		}
+	}
+	if t.style != option.FormatTable || len(t.widths) == 0 {
+		return nil
+	}
+	_, err := buf.WriteString(t.border("└", "┴", "┘"))
+	return err
+}
+
+// writeBuffered は溜めておいた行からカラムの幅を決めて、表の始まりと溜めておいた行を書き出す
+func (t *tableFormatter) writeBuffered(buf *bufio.Writer) error {
+	t.fix()
+	if len(t.widths) == 0 {
+		return nil
+	}
+
+	var head []string
+	if t.style == option.FormatTable {
+		head = append(head, t.border("┌", "┬", "┐"))
+		if t.header != nil {
+			head = append(head, t.line(t.header), t.border("├", "┼", "┤"))
+		}
+	} else {
+		head = append(head, t.line(t.header), t.delimiterRow())
+	}
+
+	for _, h := range head {
+		if _, err := buf.WriteString(h); err != nil {
+			return err
+		}
+	}
+	for _, r := range t.rows {
+		if err := t.writeRow(buf, r); err != nil {
+			return err
+		}
+	}
+	t.rows = nil
+	return nil
+}
+
+// fix は溜めておいた行からカラムの幅と、右寄せにする数値のカラムを決める
+func (t *tableFormatter) fix() {
+	t.fixed = true
+
+	n := len(t.header)
+	for _, r := range t.rows {
+		if len(r) > n {
+			n = len(r)
+		}
+	}
+
+	t.widths = make([]int, n)
+	t.numeric = make([]bool, n)
+	hasNumber := make([]bool, n)
+	for i := range t.numeric {
+		t.numeric[i] = true
+		if t.style == option.FormatTable {
+			t.widths[i] = 1
+		} else {
+			// 区切り行の --- が書けるように3文字は取っておく
+			t.widths[i] = 3
+		}
+	}
+
+	for i, h := range t.header {
+		t.widths[i] = max(t.widths[i], width.StringWidth(h))
+	}
+	for _, r := range t.rows {
+		for i, c := range r {
+			t.widths[i] = max(t.widths[i], width.StringWidth(c))
+			if len(c) == 0 {
+				continue
+			}
+			if numericValidator.MatchString(c) {
+				hasNumber[i] = true
+			} else {
+				t.numeric[i] = false
+			}
+		}
+	}
+	for i := range t.numeric {
+		t.numeric[i] = t.numeric[i] && hasNumber[i]
+	}
+}
+
+func (t *tableFormatter) writeRow(buf *bufio.Writer, cells []string) error {
+	_, err := buf.WriteString(t.line(cells))
+	return err
+}
+
+// line は1行分のセルを幅に合わせて並べる。幅を決めた後の行でカラムが多いときは、はみ出した分はそのまま並べる
+func (t *tableFormatter) line(cells []string) string {
+	sep := "|"
+	if t.style == option.FormatTable {
+		sep = "│"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sep)
+	for i := 0; i < len(t.widths) || i < len(cells); i++ {
+		var c string
+		if i < len(cells) {
+			c = cells[i]
+		}
+
+		sb.WriteByte(' ')
+		if i < len(t.widths) {
+			pad := strings.Repeat(" ", max(0, t.widths[i]-width.StringWidth(c)))
+			if t.numeric[i] {
+				sb.WriteString(pad + c)
+			} else {
+				sb.WriteString(c + pad)
+			}
+		} else {
+			sb.WriteString(c)
+		}
+		sb.WriteByte(' ')
+		sb.WriteString(sep)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// border は罫線の行を作る
+func (t *tableFormatter) border(left, middle, right string) string {
+	cells := make([]string, 0, len(t.widths))
+	for _, w := range t.widths {
+		cells = append(cells, strings.Repeat("─", w+2))
+	}
+	return left + strings.Join(cells, middle) + right + "\n"
+}
+
+// delimiterRow は Markdown の表のヘッダー行の下の区切り行を作る。markdown なら数値のカラムを ---: で右寄せにする
+func (t *tableFormatter) delimiterRow() string {
+	var sb strings.Builder
+	sb.WriteString("|")
+	for i, w := range t.widths {
+		switch {
+		case t.style == option.FormatGithub:
+			sb.WriteString(strings.Repeat("-", w+2))
+		case t.numeric[i]:
+			sb.WriteString(strings.Repeat("-", w+1) + ":")
+		default:
+			sb.WriteString(":" + strings.Repeat("-", w+1))
+		}
+		sb.WriteString("|")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// escape は表が崩れないように、セルの中の改行やタブ、Markdown では | を書き換える
+func (t *tableFormatter) escape(s string) string {
+	if t.style == option.FormatTable {
+		return tableEscaper.Replace(s)
+	}
+	return markdownEscaper.Replace(s)
+}
+
+var (
+	tableEscaper    = strings.NewReplacer("\r\n", `\n`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	markdownEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>", "\t", " ")
+)
@@ -0,0 +1,107 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/option"
+)
+
+func TestWriter_Table(t *testing.T) {
+	names := []string{"id", "name", "score"}
+	rows := [][]string{
+		{"1", "アリス", "9.5"},
+		{"20", "b|ob", ""},
+		{"3", "line\nbreak", "-1"},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		header bool
+		sample int
+		want   string
+	}{
+		{
+			name:   "table with header",
+			format: option.FormatTable,
+			header: true,
+			want: "" +
+				"┌────┬─────────────┬───────┐\n" +
+				"│ id │ name        │ score │\n" +
+				"├────┼─────────────┼───────┤\n" +
+				"│  1 │ アリス      │   9.5 │\n" +
+				"│ 20 │ b|ob        │       │\n" +
+				"│  3 │ line\\nbreak │    -1 │\n" +
+				"└────┴─────────────┴───────┘\n",
+		},
+		{
+			name:   "table without header",
+			format: option.FormatTable,
+			want: "" +
+				"┌────┬─────────────┬─────┐\n" +
+				"│  1 │ アリス      │ 9.5 │\n" +
+				"│ 20 │ b|ob        │     │\n" +
+				"│  3 │ line\\nbreak │  -1 │\n" +
+				"└────┴─────────────┴─────┘\n",
+		},
+		{
+			name:   "markdown with header",
+			format: option.FormatMarkdown,
+			header: true,
+			want: "" +
+				"|  id | name          | score |\n" +
+				"|----:|:--------------|------:|\n" +
+				"|   1 | アリス        |   9.5 |\n" +
+				"|  20 | b\\|ob         |       |\n" +
+				"|   3 | line<br>break |    -1 |\n",
+		},
+		{
+			name:   "github without header uses names",
+			format: option.FormatGithub,
+			want: "" +
+				"|  id | name          | score |\n" +
+				"|-----|---------------|-------|\n" +
+				"|   1 | アリス        |   9.5 |\n" +
+				"|  20 | b\\|ob         |       |\n" +
+				"|   3 | line<br>break |    -1 |\n",
+		},
+		{
+			name:   "table with sample",
+			format: option.FormatTable,
+			sample: 1,
+			want: "" +
+				"┌───┬────────┬─────┐\n" +
+				"│ 1 │ アリス │ 9.5 │\n" +
+				"│ 20 │ b|ob   │     │\n" +
+				"│ 3 │ line\\nbreak │  -1 │\n" +
+				"└───┴────────┴─────┘\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewWriter(option.Option{
+				OutputOption: option.OutputOption{OutputFormat: tt.format, TableSample: tt.sample},
+				HeaderOption: option.HeaderOption{UseHeader: tt.header},
+			}, buf, false)
+			for _, r := range rows {
+				for i, c := range r {
+					assert.NoError(t, w.WriteNamed(names[i], c))
+				}
+				assert.NoError(t, w.WriteNewLine())
+			}
+			assert.NoError(t, w.Close())
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWriter_Table_Empty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(option.Option{OutputOption: option.OutputOption{OutputFormat: option.FormatTable}}, buf, false)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "", buf.String())
+}
@@ -2,6 +2,7 @@ package output
 
 import (
 	"bufio"
+	"github.com/xztaityozx/sel/internal/charset"
 	"github.com/xztaityozx/sel/internal/option"
 	"io"
 	"text/template"
@@ -14,10 +15,23 @@ type Writer struct {
 	writtenColumns int
 	outputTemplate *template.Template
 	column         []string
+	// --output-format のときの書き出し方。nil なら delimiter で区切って書き出す
+	format formatter
+	// column のそれぞれにつけた名前。WriteNamed で書き込んだもの以外は空文字列
+	names []string
+	// Capture されている間の書き込み先。入れ子にできるようにスタックになっている
+	captures [][]string
+	// header はヘッダー行をまだ書き出していなければ true。--keep-header や --emit-header のときだけ使う
+	header bool
+	// emitHeader なら最初の行を書き出す前に、その行のカラムの名前をヘッダー行として書き出す
+	// WriteHeader でヘッダー行を先に書き出すこともできる
+	emitHeader bool
+	// lineEnd は1行の終わりに書き込むレコードの区切り。--output-record-separator や -z で変えられる
+	lineEnd []byte
+	// encoder は --output-encoding のときに buf の書き込み先になる。Close で残りを書き出す
+	encoder io.WriteCloser
 }
 
-var newLine = []byte("\n")
-
 const shrinkThreshold = 64
 
 func resetStringSlice(s []string) []string {
@@ -28,20 +42,50 @@ func resetStringSlice(s []string) []string {
 }
 
 func NewWriter(option option.Option, w io.Writer, autoFlush bool) *Writer {
+	encoder := charset.NewWriter(w, option.OutputEncoding)
+	if encoder != nil {
+		w = encoder
+	}
 	return &Writer{
 		delimiter:      []byte(option.OutPutDelimiter),
 		buf:            bufio.NewWriter(w),
+		encoder:        encoder,
 		autoFlush:      autoFlush,
 		outputTemplate: option.Template,
 		column:         []string{},
+		format:         newFormatter(option),
+		header:         option.IsHeaderOutput(),
+		emitHeader:     option.EmitHeader,
+		lineEnd:        []byte(option.OutputLineEnd()),
 	}
 }
 
 func (w *Writer) Write(columns ...string) error {
+	return w.WriteNamed("", columns...)
+}
+
+// WriteNamed は columns に name という名前をつけて書き込む。名前は --output-format json のオブジェクトのキーになる
+// 名前を使わない出力では Write と同じ
+func (w *Writer) WriteNamed(name string, columns ...string) error {
 	if len(columns) == 0 {
 		return nil
 	}
 
+	if n := len(w.captures); n != 0 {
+		w.captures[n-1] = append(w.captures[n-1], columns...)
+		return nil
+	}
+
+	if w.format != nil || w.pendingHeader() {
+		// --output-format のときも、1行分のカラムが揃ってから WriteNewLine() で書き出す
+		// --emit-header で最初の行を書き出すときも、カラムの名前が揃うまで待つ
+		w.column = append(w.column, columns...)
+		for range columns {
+			w.names = append(w.names, name)
+		}
+		return nil
+	}
+
 	if w.outputTemplate != nil {
 		// テンプレートを使うときは、出力すべきすべてのカラムが揃ってから書き出すので、ここにはバッファに乗せるのみ
 		// 実際の書き込みは WriteNewLine() で行う
@@ -77,9 +121,40 @@ func (w *Writer) Write(columns ...string) error {
 	return nil
 }
 
+// Capture は Release するまでの Write を書き出さずに溜めておくようにする
+// Selector が書き出す値を加工したいときに使う
+func (w *Writer) Capture() {
+	w.captures = append(w.captures, nil)
+}
+
+// Release は最後の Capture から溜めた値を返して、Capture する前の状態に戻す
+func (w *Writer) Release() []string {
+	n := len(w.captures)
+	if n == 0 {
+		return nil
+	}
+	rt := w.captures[n-1]
+	w.captures = w.captures[:n-1]
+	return rt
+}
+
 // WriteNewLine は改行を書き込む。テンプレートを利用している場合は、テンプレートを使った書き込みを行う
 func (w *Writer) WriteNewLine() error {
+	if w.pendingHeader() {
+		return w.writeWithHeader()
+	}
+
 	// ref: Write(columns ...string) error
+	if w.format != nil {
+		err := w.format.row(w.buf, w.names, w.column)
+		w.column = resetStringSlice(w.column)
+		w.names = resetStringSlice(w.names)
+		if err != nil || !w.autoFlush {
+			return err
+		}
+		return w.buf.Flush()
+	}
+
 	if w.outputTemplate != nil {
 		err := w.outputTemplate.Execute(w.buf, w.column)
 		if err != nil {
@@ -89,10 +164,68 @@ func (w *Writer) WriteNewLine() error {
 	}
 
 	w.writtenColumns = 0
-	_, err := w.buf.Write(newLine)
+	_, err := w.buf.Write(w.lineEnd)
 	return err
 }
 
+// TakeHeader はヘッダー行をまだ書き出していなければ true を返して、書き出したことにする
+// --keep-header で、複数のファイルのヘッダー行のうち最初の1つだけを書き出すのに使う
+func (w *Writer) TakeHeader() bool {
+	if !w.header {
+		return false
+	}
+	w.header = false
+	return true
+}
+
+func (w *Writer) pendingHeader() bool {
+	return w.emitHeader && w.header
+}
+
+// WriteHeader は --emit-header のヘッダー行をまだ書き出していなければ、names をヘッダー行として書き出す
+// --header のヘッダー行を読んだときや、1行も書き出さずにすべての入力を読み終えたときに使う
+func (w *Writer) WriteHeader(names ...string) error {
+	if !w.pendingHeader() {
+		return nil
+	}
+	w.header = false
+
+	if err := w.Write(names...); err != nil {
+		return err
+	}
+	return w.WriteNewLine()
+}
+
+// writeWithHeader は溜めておいた最初の行のカラムの名前をヘッダー行として書き出してから、その行を書き出す
+func (w *Writer) writeWithHeader() error {
+	names, columns := w.names, w.column
+	w.names, w.column = nil, nil
+
+	if err := w.WriteHeader(names...); err != nil {
+		return err
+	}
+	if err := w.Write(columns...); err != nil {
+		return err
+	}
+	return w.WriteNewLine()
+}
+
 func (w *Writer) Flush() error {
 	return w.buf.Flush()
 }
+
+// Close はすべての入力を書き出した後に呼ぶ。--output-format json の閉じ括弧のような出力の終わりを書き込んで Flush する
+func (w *Writer) Close() error {
+	if w.format != nil {
+		if err := w.format.close(w.buf); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/xztaityozx/sel/internal/option"
+	"golang.org/x/text/encoding/japanese"
 )
 
 func TestNewWriter(t *testing.T) {
@@ -106,3 +107,101 @@ func BenchmarkWriter_WriteNewLine_Template(b *testing.B) {
 		_ = w.WriteNewLine()
 	}
 }
+
+func TestWriter_Capture(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "}}, buf, false)
+
+	assert.NoError(t, w.Write("a"))
+
+	w.Capture()
+	assert.NoError(t, w.Write("b", "c"))
+
+	w.Capture()
+	assert.NoError(t, w.Write("d"))
+	assert.Equal(t, []string{"d"}, w.Release(), "入れ子の Capture は内側の分だけ返すべき")
+
+	assert.Equal(t, []string{"b", "c"}, w.Release())
+	assert.Nil(t, w.Release(), "Capture していないときは nil を返すべき")
+
+	assert.NoError(t, w.Write("e"))
+	assert.NoError(t, w.WriteNewLine())
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "a e\n", buf.String(), "Capture した値は書き出されないべき")
+}
+
+func TestWriter_EmitHeader(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(option.Option{
+		DelimiterOption: option.DelimiterOption{OutPutDelimiter: ","},
+		HeaderOption:    option.HeaderOption{EmitHeader: true},
+	}, buf, false)
+
+	for _, row := range [][]string{{"1", "alice"}, {"2", "bob"}} {
+		assert.NoError(t, w.WriteNamed("id", row[0]))
+		assert.NoError(t, w.WriteNamed("name", row[1]))
+		assert.NoError(t, w.WriteNewLine())
+	}
+	assert.False(t, w.TakeHeader(), "ヘッダー行は書き出し済みになるべき")
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "id,name\n1,alice\n2,bob\n", buf.String())
+}
+
+func TestWriter_WriteHeader(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(option.Option{
+		DelimiterOption: option.DelimiterOption{OutPutDelimiter: ","},
+		HeaderOption:    option.HeaderOption{EmitHeader: true},
+	}, buf, false)
+
+	assert.NoError(t, w.WriteHeader("id", "name"))
+	assert.NoError(t, w.WriteHeader("x", "y"), "2回目からは何も書き出さないべき")
+	assert.NoError(t, w.WriteNamed("x", "1"))
+	assert.NoError(t, w.WriteNamed("y", "alice"))
+	assert.NoError(t, w.WriteNewLine())
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "id,name\n1,alice\n", buf.String(), "行を書き出す前に書き出したヘッダー行を使うべき")
+
+	buf.Reset()
+	w = NewWriter(option.Option{DelimiterOption: option.DelimiterOption{OutPutDelimiter: ","}}, buf, false)
+	assert.NoError(t, w.WriteHeader("id", "name"))
+	assert.NoError(t, w.Close())
+	assert.Empty(t, buf.String(), "--emit-header でなければ何も書き出さないべき")
+}
+
+func TestWriter_TakeHeader(t *testing.T) {
+	w := NewWriter(option.Option{HeaderOption: option.HeaderOption{KeepHeader: true}}, &bytes.Buffer{}, false)
+	assert.True(t, w.TakeHeader())
+	assert.False(t, w.TakeHeader(), "ヘッダー行は1回だけ書き出すべき")
+
+	w = NewWriter(option.Option{}, &bytes.Buffer{}, false)
+	assert.False(t, w.TakeHeader(), "--keep-header でなければヘッダー行は書き出さないべき")
+}
+
+func TestWriter_OutputRecordSeparator(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(option.Option{
+		DelimiterOption: option.DelimiterOption{OutPutDelimiter: " "},
+		RecordOption:    option.RecordOption{OutputRecordSeparator: "\x00"},
+	}, buf, false)
+
+	for _, v := range []string{"a", "b"} {
+		assert.NoError(t, w.Write(v, v))
+		assert.NoError(t, w.WriteNewLine())
+	}
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "a a\x00b b\x00", buf.String())
+}
+
+func TestWriter_OutputEncoding(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(option.Option{
+		DelimiterOption: option.DelimiterOption{OutPutDelimiter: ","},
+		EncodingOption:  option.EncodingOption{OutputEncoding: japanese.ShiftJIS},
+	}, buf, false)
+
+	assert.NoError(t, w.Write("名前", "太郎"))
+	assert.NoError(t, w.WriteNewLine())
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "\x96\xbc\x91\x4f,\x91\xbe\x98\x59\n", buf.String())
+}
@@ -4,36 +4,36 @@ import "testing"
 
 func BenchmarkParse_SingleIndex(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, _ = Parse([]string{"1"})
+		_, _ = Parse([]string{"1"}, false)
 	}
 }
 
 func BenchmarkParse_MultipleIndex(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, _ = Parse([]string{"1", "2", "3", "4", "5"})
+		_, _ = Parse([]string{"1", "2", "3", "4", "5"}, false)
 	}
 }
 
 func BenchmarkParse_Range(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, _ = Parse([]string{"1:10"})
+		_, _ = Parse([]string{"1:10"}, false)
 	}
 }
 
 func BenchmarkParse_RangeWithStep(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, _ = Parse([]string{"1:10:2"})
+		_, _ = Parse([]string{"1:10:2"}, false)
 	}
 }
 
 func BenchmarkParse_Regexp(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, _ = Parse([]string{"/start/:/end/"})
+		_, _ = Parse([]string{"/start/:/end/"}, false)
 	}
 }
 
 func BenchmarkParse_Complex(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_, _ = Parse([]string{"1", "2:10", "/start/:/end/", "-1", "3::2"})
+		_, _ = Parse([]string{"1", "2:10", "/start/:/end/", "-1", "3::2"}, false)
 	}
 }
@@ -3,79 +3,335 @@ package parser
 import (
 	"fmt"
 	"github.com/xztaityozx/sel/internal/column"
+	"github.com/xztaityozx/sel/internal/pipe"
 	"strconv"
 	"strings"
 )
 
-func Parse(args []string) ([]column.Selector, error) {
+// Parse はクエリを column.Selector にする
+// allowNames が false のときはカラム名や @/regexp/ のクエリを受け付けない。--header や --ltsv のようにカラム名がわかる入力のときだけ true にする
+func Parse(args []string, allowNames bool) ([]column.Selector, error) {
+	rt, _, err := ParseNamed(args, allowNames)
+	return rt, err
+}
+
+// ParseNamed は Parse と同じで、それぞれの column.Selector のもとになったクエリの文字列も返す
+// まとめられた !query は空白で連結する
+func ParseNamed(args []string, allowNames bool) ([]column.Selector, []string, error) {
 	queries := make(QuerySlice, 0, len(args))
 	for _, v := range args {
 		queries = append(queries, Query(v))
 	}
 
 	rt := make([]column.Selector, 0, len(args))
-	var err error
+	names := make([]string, 0, len(args))
+	// 連続する !query はまとめて1つの ComplementSelector にする
+	// sel '!3' '!7' は「3番目と7番目以外」になる
+	var excludes []column.Indexer
+	var excludeNames []string
 	for _, query := range queries {
-		if query.isIndexQuery() {
-			querySection := strings.Split(string(query), ":")
-			if len(querySection) == 1 {
+		if !query.isComplementQuery() {
+			if len(excludes) != 0 {
+				rt = append(rt, column.NewComplementSelector(excludes...))
+				names = append(names, strings.Join(excludeNames, " "))
+				excludes, excludeNames = nil, nil
+			}
+
+			s, err := parseQuery(query, allowNames)
+			if err != nil {
+				return nil, nil, err
+			}
+			rt = append(rt, s)
+			names = append(names, string(query))
+			continue
+		}
+
+		q := Query(strings.TrimPrefix(string(query), "!"))
+		if q.isComplementQuery() {
+			return nil, nil, fmt.Errorf("%s: complement query cannot be nested", query)
+		}
+
+		s, err := parseQuery(q, allowNames)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		indexer, ok := s.(column.Indexer)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s cannot be used in complement query", query)
+		}
+		excludes = append(excludes, indexer)
+		excludeNames = append(excludeNames, string(query))
+	}
+
+	if len(excludes) != 0 {
+		rt = append(rt, column.NewComplementSelector(excludes...))
+		names = append(names, strings.Join(excludeNames, " "))
+	}
+
+	return rt, names, nil
+}
+
+// parseQuery は1つのクエリを column.Selector にする。 | でパイプが続いているときは column.PipeSelector で包む
+func parseQuery(query Query, allowNames bool) (column.Selector, error) {
+	base, pipes, err := query.splitPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := parseNested(base, allowNames)
+	if err != nil || len(pipes) == 0 {
+		return s, err
+	}
+
+	stages := make([]pipe.Stage, 0, len(pipes))
+	for _, p := range pipes {
+		stage, err := pipe.Parse(p)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+	return column.NewPipeSelector(s, stages...), nil
+}
+
+// parseNested は 4[,]2 や 4{d=","}.2:3 のような入れ子のクエリを column.NestedSelector にする
+// 入れ子になっていなければ parseSelector と同じ
+func parseNested(query Query, allowNames bool) (column.Selector, error) {
+	if query.isJSONPathQuery() {
+		// JSON のパスの [] は入れ子のクエリではない
+		return parseSelector(query, allowNames)
+	}
+
+	nq, ok, err := query.splitNested()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return parseSelector(query, allowNames)
+	}
+
+	if len(nq.inner) == 0 {
+		if nq.brace {
+			return nil, fmt.Errorf("%s: query is required after delimiter", query)
+		}
+		// 後ろにクエリがなければ 3[1:8] のようにカラムの一部を切り出すクエリ
+		return parseSlice(nq.outer, nq.spec, allowNames)
+	}
+
+	delimiter, useRegexp, err := parseDelimiterSpec(nq.spec, nq.brace)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", query, err)
+	}
+
+	outer, err := parseSelector(nq.outer, allowNames)
+	if err != nil {
+		return nil, err
+	}
+
+	// 内側のクエリはさらに入れ子になっていてもよい
+	inner, err := parseNested(nq.inner, allowNames)
+	if err != nil {
+		return nil, err
+	}
+
+	return column.NewNestedSelector(outer, inner, delimiter, useRegexp)
+}
+
+// parseSlice は 3[1:8] や 0[b-10:] のようなカラムの一部を切り出すクエリを column.SliceSelector にする
+//
+//	[2]       2文字目
+//	[1:8]     1文字目から8文字目
+//	[-3:]     末尾の3文字
+//	[::-1]    逆順
+//	[b1:8]    1バイト目から8バイト目
+//	[w1:8]    表示幅で1桁目から8桁目
+func parseSlice(outer Query, spec string, allowNames bool) (column.Selector, error) {
+	m := sliceSpecValidator.FindStringSubmatch(spec)
+	if m == nil || len(spec) == len(m[1]) {
+		return nil, fmt.Errorf("%s[%s]: query is required after delimiter, or [%s] is invalid range", outer, spec, spec)
+	}
+
+	inner, err := parseSelector(outer, allowNames)
+	if err != nil {
+		return nil, err
+	}
+
+	unit := column.SliceByRune
+	switch m[1] {
+	case "b":
+		unit = column.SliceByByte
+	case "w":
+		unit = column.SliceByWidth
+	}
+
+	if len(m[3]) == 0 {
+		idx, err := strconv.Atoi(m[2])
+		if err != nil {
+			return nil, err
+		}
+		if idx == 0 {
+			return nil, fmt.Errorf("%s[%s]: position starts from 1", outer, spec)
+		}
+		return column.NewSliceIndexSelector(inner, unit, idx), nil
+	}
+
+	step, err := parseStep([]string{m[2], m[4], m[6]})
+	if err != nil {
+		return nil, err
+	}
+
+	// 省略されたときは step の向きに合わせて端から端まで
+	start, stop, isInfStop := 1, 0, true
+	if step < 0 {
+		start, stop, isInfStop = -1, 1, false
+	}
+	if len(m[2]) != 0 {
+		if start, err = strconv.Atoi(m[2]); err != nil {
+			return nil, err
+		}
+	}
+	if len(m[4]) != 0 {
+		if stop, err = strconv.Atoi(m[4]); err != nil {
+			return nil, err
+		}
+		isInfStop = false
+	}
+	if start == 0 || (stop == 0 && !isInfStop) {
+		return nil, fmt.Errorf("%s[%s]: position starts from 1", outer, spec)
+	}
+
+	return column.NewSliceSelector(inner, unit, start, step, stop, isInfStop), nil
+}
+
+// parseDelimiterSpec は入れ子のクエリの区切り文字の指定を読む
+//
+//	[,]       ',' で分割
+//	[/\s+/]   正規表現で分割
+//	{d=","}   ',' で分割
+//	{d=/\s+/} 正規表現で分割
+func parseDelimiterSpec(spec string, brace bool) (string, bool, error) {
+	if brace {
+		key, value, found := strings.Cut(strings.TrimSpace(spec), "=")
+		if !found || strings.TrimSpace(key) != "d" {
+			return "", false, fmt.Errorf("{%s} is invalid, use {d=\"delimiter\"} or {d=/regexp/}", spec)
+		}
+
+		spec = strings.TrimSpace(value)
+		if len(spec) >= 2 && spec[0] == '"' && spec[len(spec)-1] == '"' {
+			spec = strings.ReplaceAll(spec[1:len(spec)-1], `\"`, `"`)
+			if len(spec) == 0 {
+				return "", false, fmt.Errorf("delimiter cannot be empty")
+			}
+			return spec, false, nil
+		}
+	}
+
+	if len(spec) == 0 {
+		return "", false, fmt.Errorf("delimiter cannot be empty")
+	}
+
+	if len(spec) > 2 && spec[0] == '/' && spec[len(spec)-1] == '/' {
+		return spec[1 : len(spec)-1], true, nil
+	}
+	return spec, false, nil
+}
+
+// parseSelector はパイプや入れ子を含まないクエリを column.Selector にする
+func parseSelector(query Query, allowNames bool) (column.Selector, error) {
+	if query.isIndexQuery() {
+		querySection := strings.Split(string(query), ":")
+		if len(querySection) == 1 {
+			idx, err := strconv.Atoi(querySection[0])
+			if err != nil {
+				return nil, err
+			}
+			return column.NewIndexSelector(idx), nil
+		} else if len(querySection) == 2 || len(querySection) == 3 {
+			start := 1
+			if len(querySection[0]) != 0 {
 				idx, err := strconv.Atoi(querySection[0])
 				if err != nil {
 					return nil, err
 				}
-				rt = append(rt, column.NewIndexSelector(idx))
-			} else if len(querySection) == 2 || len(querySection) == 3 {
-				start := 1
-				if len(querySection[0]) != 0 {
-					idx, err := strconv.Atoi(querySection[0])
-					if err != nil {
-						return nil, err
-					}
-					start = idx
-				}
-
-				isInfStop := true
-				stop := start
-				if len(querySection[1]) != 0 {
-					idx, err := strconv.Atoi(querySection[1])
-					if err != nil {
-						return nil, err
-					}
-					stop = idx
-					isInfStop = false
-				}
-
-				step := 1
-				if len(querySection) == 3 && len(querySection[2]) != 0 {
-					step, err = strconv.Atoi(querySection[2])
-					if err != nil {
-						return nil, err
-					}
-				}
+				start = idx
+			}
 
-				if step == 0 {
-					return nil, fmt.Errorf("step cannot be zero")
+			isInfStop := true
+			stop := start
+			if len(querySection[1]) != 0 {
+				idx, err := strconv.Atoi(querySection[1])
+				if err != nil {
+					return nil, err
 				}
-
-				rt = append(rt, column.NewRangeSelector(start, step, stop, isInfStop))
-			} else {
-				return nil, fmt.Errorf("%s is invalid index query", query)
+				stop = idx
+				isInfStop = false
 			}
-		} else if query.isSwitchQuery() {
-			// sedやawkの2addrみたいなやつ
-			// /regexp/,/regexp/
-			// /regexp/,number
-			// number,/regexp/
-			s := switchQueryValidator.FindAllStringSubmatch(string(query), -1)[0]
-			ss, err := column.NewSwitchSelector(s[1], s[2])
+
+			step, err := parseStep(querySection)
 			if err != nil {
 				return nil, err
 			}
-			rt = append(rt, ss)
+
+			return column.NewRangeSelector(start, step, stop, isInfStop), nil
 		} else {
-			return nil, fmt.Errorf("%s is invalid query", query)
+			return nil, fmt.Errorf("%s is invalid index query", query)
+		}
+	} else if query.isSwitchQuery() {
+		// sedやawkの2addrみたいなやつ
+		// /regexp/,/regexp/
+		// /regexp/,number
+		// number,/regexp/
+		s := switchQueryValidator.FindAllStringSubmatch(string(query), -1)[0]
+		return column.NewSwitchSelector(s[1], s[2])
+	} else if query.isExpressionQuery() {
+		// 式を評価した結果をカラムとして出力するやつ
+		// =( $3 * 1000 )
+		s := expressionQueryValidator.FindStringSubmatch(string(query))
+		return column.NewExpressionSelector(s[1])
+	} else if allowNames && query.isHeaderRegexpQuery() {
+		// ヘッダーのカラム名か --ltsv のラベルが正規表現にマッチするカラムをすべて選ぶやつ
+		// @/regexp/
+		// /regexp/
+		s := headerRegexpQueryValidator.FindStringSubmatch(string(query))
+		return column.NewHeaderRegexpSelector(s[1])
+	} else if query.isJSONPathQuery() {
+		// --jsonl のときに JSON の値をパスで選ぶやつ
+		// .user.id
+		// .items[0].sku
+		return column.NewJSONPathSelector(string(query))
+	} else if allowNames && query.isNameQuery() {
+		// ヘッダーのカラム名を使うやつ。実際の index はヘッダー行を読んでから決まる
+		querySection := strings.Split(string(query), ":")
+		if len(querySection) == 1 {
+			return column.NewNameSelector(querySection[0]), nil
+		}
+
+		step, err := parseStep(querySection)
+		if err != nil {
+			return nil, err
+		}
+
+		return column.NewNameRangeSelector(querySection[0], querySection[1], step), nil
+	}
+
+	return nil, fmt.Errorf("%s is invalid query", query)
+}
+
+// parseStep は start:stop:step の step を取り出す。省略されていたら 1
+func parseStep(querySection []string) (int, error) {
+	step := 1
+	if len(querySection) == 3 && len(querySection[2]) != 0 {
+		var err error
+		step, err = strconv.Atoi(querySection[2])
+		if err != nil {
+			return 0, err
 		}
 	}
 
-	return rt, nil
+	if step == 0 {
+		return 0, fmt.Errorf("step cannot be zero")
+	}
+
+	return step, nil
 }
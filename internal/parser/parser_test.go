@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"github.com/stretchr/testify/assert"
 	"github.com/xztaityozx/sel/internal/column"
 	"reflect"
 	"testing"
@@ -11,6 +12,16 @@ func newSwitchSelector(begin, end string) column.SwitchSelector {
 	return s
 }
 
+func newHeaderRegexpSelector(pattern string) column.HeaderRegexpSelector {
+	s, _ := column.NewHeaderRegexpSelector(pattern)
+	return s
+}
+
+func newExpressionSelector(src string) column.ExpressionSelector {
+	s, _ := column.NewExpressionSelector(src)
+	return s
+}
+
 func TestParse(t *testing.T) {
 	type args struct {
 		queries []string
@@ -95,10 +106,68 @@ func TestParse(t *testing.T) {
 				newSwitchSelector("/xyz/", "/abc/"),
 			},
 		},
+		{
+			name: "user_id status:-1 1:name::2", args: args{queries: []string{"user_id", "status:-1", "1:name:2"}}, want: []column.Selector{
+				column.NewNameSelector("user_id"),
+				column.NewNameRangeSelector("status", "-1", 1),
+				column.NewNameRangeSelector("1", "name", 2),
+			},
+		},
+		{
+			name: "name: :name", args: args{queries: []string{"name:", ":name"}}, want: []column.Selector{
+				column.NewNameRangeSelector("name", "", 1),
+				column.NewNameRangeSelector("", "name", 1),
+			},
+		},
+		{
+			name: "@/^metric_/ @timestamp", args: args{queries: []string{"@/^metric_/", "@timestamp"}}, want: []column.Selector{
+				newHeaderRegexpSelector("^metric_"),
+				column.NewNameSelector("@timestamp"),
+			},
+		},
+		{
+			name: "@/(/", args: args{queries: []string{"@/(/"}}, wantErr: true,
+		},
+		{
+			name: "!3 !7 1 !2:4", args: args{queries: []string{"!3", "!7", "1", "!2:4"}}, want: []column.Selector{
+				column.NewComplementSelector(column.NewIndexSelector(3), column.NewIndexSelector(7)),
+				column.NewIndexSelector(1),
+				column.NewComplementSelector(column.NewRangeSelector(2, 1, 4, false)),
+			},
+		},
+		{
+			name: "!/a/:/b/ !name", args: args{queries: []string{"!/a/:/b/", "!name"}}, want: []column.Selector{
+				column.NewComplementSelector(newSwitchSelector("/a/", "/b/"), column.NewNameSelector("name")),
+			},
+		},
+		{
+			name: "!!1", args: args{queries: []string{"!!1"}}, wantErr: true,
+		},
+		{
+			name: "=( $3 * 1000 )", args: args{queries: []string{"=( $3 * 1000 )", "=($1 . $2)"}}, want: []column.Selector{
+				newExpressionSelector(" $3 * 1000 "),
+				newExpressionSelector("$1 . $2"),
+			},
+		},
+		{
+			name: "=( $3 * )", args: args{queries: []string{"=( $3 * )"}}, wantErr: true,
+		},
+		{
+			name: "3|nothing", args: args{queries: []string{"3|nothing"}}, wantErr: true,
+		},
+		{
+			name: "!3|upper", args: args{queries: []string{"!3|upper"}}, wantErr: true,
+		},
+		{
+			name: "name:name:0", args: args{queries: []string{"a:b:0"}}, wantErr: true,
+		},
+		{
+			name: "name:name:x", args: args{queries: []string{"a:b:x"}}, wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := Parse(tt.args.queries)
+			got, err := Parse(tt.args.queries, true)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -109,3 +178,244 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestParse_Pipe(t *testing.T) {
+	got, err := Parse([]string{"3|upper", "1:2|trim|substr(0,8)", `/a|b/:/c/|replace("|", "-")`}, true)
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+	for _, s := range got {
+		assert.IsType(t, column.PipeSelector{}, s)
+	}
+}
+
+func TestQuery_splitPipe(t *testing.T) {
+	tests := []struct {
+		query     Query
+		wantBase  Query
+		wantPipes []string
+		wantErr   bool
+	}{
+		{query: "3", wantBase: "3", wantPipes: []string{}},
+		{query: "3|upper", wantBase: "3", wantPipes: []string{"upper"}},
+		{query: "3|upper|substr(0,8)", wantBase: "3", wantPipes: []string{"upper", "substr(0,8)"}},
+		{query: "/a|b/:/c|d/|lower", wantBase: "/a|b/:/c|d/", wantPipes: []string{"lower"}},
+		{query: `@/a\/|b/|lower`, wantBase: `@/a\/|b/`, wantPipes: []string{"lower"}},
+		{query: `=( $1 || $2 )|upper`, wantBase: `=( $1 || $2 )`, wantPipes: []string{"upper"}},
+		{query: `1|replace("|", ")")`, wantBase: "1", wantPipes: []string{`replace("|", ")")`}},
+		{query: "km/h|trim", wantBase: "km/h", wantPipes: []string{"trim"}},
+		{query: "/a|b", wantErr: true},
+		{query: "1|substr(0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.query), func(t *testing.T) {
+			base, pipes, err := tt.query.splitPipe()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBase, base)
+			assert.Equal(t, tt.wantPipes, pipes)
+		})
+	}
+}
+
+func TestParse_Nested(t *testing.T) {
+	got, err := Parse([]string{"4[,]2", `4[/\s*,\s*/]2`, `4{d=","}.2:3`, "4{d=/;/}.1", "4[,]2[:]1", "4[|]2|upper", "tags[,]1"}, true)
+	assert.NoError(t, err)
+	assert.Len(t, got, 7)
+	for _, s := range got[:5] {
+		assert.IsType(t, column.NestedSelector{}, s)
+	}
+	assert.IsType(t, column.PipeSelector{}, got[5])
+	assert.IsType(t, column.NestedSelector{}, got[6])
+
+	for _, q := range []string{"4[,]", "4{d=\",\"}.", "4{x=1}.2", `4{d=","}2`, `4{d=""}.2`, "4[]2", "4[,}2", "4[,2", "4[/(/]1", "!4[,]2"} {
+		t.Run(q, func(t *testing.T) {
+			_, err := Parse([]string{q}, true)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestQuery_splitNested(t *testing.T) {
+	tests := []struct {
+		query   Query
+		want    nestedQuery
+		wantOk  bool
+		wantErr bool
+	}{
+		{query: "3", wantOk: false},
+		{query: "=( $1 . $2 )", wantOk: false},
+		{query: "/a[0-9]/:/b/", wantOk: false},
+		{query: "4[,]2", want: nestedQuery{outer: "4", spec: ",", inner: "2"}, wantOk: true},
+		{query: "1:3[;]-1", want: nestedQuery{outer: "1:3", spec: ";", inner: "-1"}, wantOk: true},
+		{query: `4[/\s*,\s*/]2`, want: nestedQuery{outer: "4", spec: `/\s*,\s*/`, inner: "2"}, wantOk: true},
+		{query: `4{d=","}.2:3`, want: nestedQuery{outer: "4", spec: `d=","`, inner: "2:3", brace: true}, wantOk: true},
+		{query: `4{d="}"}.1`, want: nestedQuery{outer: "4", spec: `d="}"`, inner: "1", brace: true}, wantOk: true},
+		{query: "4[,]2[:]1", want: nestedQuery{outer: "4", spec: ",", inner: "2[:]1"}, wantOk: true},
+		{query: "/a/:/b/[,]1", want: nestedQuery{outer: "/a/:/b/", spec: ",", inner: "1"}, wantOk: true},
+		{query: `4{d=","}2`, wantErr: true},
+		{query: "4[,}2", wantErr: true},
+		{query: "4[,2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.query), func(t *testing.T) {
+			got, ok, err := tt.query.splitNested()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseDelimiterSpec(t *testing.T) {
+	tests := []struct {
+		spec          string
+		brace         bool
+		wantDelimiter string
+		wantRegexp    bool
+		wantErr       bool
+	}{
+		{spec: ",", wantDelimiter: ","},
+		{spec: "::", wantDelimiter: "::"},
+		{spec: `/\s+/`, wantDelimiter: `\s+`, wantRegexp: true},
+		{spec: "/", wantDelimiter: "/"},
+		{spec: `d=","`, brace: true, wantDelimiter: ","},
+		{spec: ` d = "\"" `, brace: true, wantDelimiter: `"`},
+		{spec: `d=/;+/`, brace: true, wantDelimiter: ";+", wantRegexp: true},
+		{spec: "", wantErr: true},
+		{spec: `d=""`, brace: true, wantErr: true},
+		{spec: `x=","`, brace: true, wantErr: true},
+		{spec: ",", brace: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			delimiter, useRegexp, err := parseDelimiterSpec(tt.spec, tt.brace)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDelimiter, delimiter)
+			assert.Equal(t, tt.wantRegexp, useRegexp)
+		})
+	}
+}
+
+func TestParse_Slice(t *testing.T) {
+	tests := []struct {
+		query string
+		want  column.Selector
+	}{
+		{query: "3[2]", want: column.NewSliceIndexSelector(column.NewIndexSelector(3), column.SliceByRune, 2)},
+		{query: "3[-1]", want: column.NewSliceIndexSelector(column.NewIndexSelector(3), column.SliceByRune, -1)},
+		{query: "3[1:8]", want: column.NewSliceSelector(column.NewIndexSelector(3), column.SliceByRune, 1, 1, 8, false)},
+		{query: "0[10:20]", want: column.NewSliceSelector(column.NewIndexSelector(0), column.SliceByRune, 10, 1, 20, false)},
+		{query: "3[-3:]", want: column.NewSliceSelector(column.NewIndexSelector(3), column.SliceByRune, -3, 1, 0, true)},
+		{query: "3[:]", want: column.NewSliceSelector(column.NewIndexSelector(3), column.SliceByRune, 1, 1, 0, true)},
+		{query: "3[::2]", want: column.NewSliceSelector(column.NewIndexSelector(3), column.SliceByRune, 1, 2, 0, true)},
+		{query: "3[::-1]", want: column.NewSliceSelector(column.NewIndexSelector(3), column.SliceByRune, -1, -1, 1, false)},
+		{query: "3[b1:8]", want: column.NewSliceSelector(column.NewIndexSelector(3), column.SliceByByte, 1, 1, 8, false)},
+		{query: "3[w:8]", want: column.NewSliceSelector(column.NewIndexSelector(3), column.SliceByWidth, 1, 1, 8, false)},
+		{query: "1:3[1:2]", want: column.NewSliceSelector(column.NewRangeSelector(1, 1, 3, false), column.SliceByRune, 1, 1, 2, false)},
+		{query: "name[1:2]", want: column.NewSliceSelector(column.NewNameSelector("name"), column.SliceByRune, 1, 1, 2, false)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := Parse([]string{tt.query}, true)
+			assert.NoError(t, err)
+			assert.Equal(t, []column.Selector{tt.want}, got)
+		})
+	}
+
+	got, err := Parse([]string{"4[,]2[1:3]", "3[1:8]|upper"}, true)
+	assert.NoError(t, err)
+	assert.IsType(t, column.NestedSelector{}, got[0])
+	assert.IsType(t, column.PipeSelector{}, got[1])
+
+	for _, q := range []string{"3[0]", "3[0:2]", "3[1:0]", "3[1:2:0]", "3[b]", "3[x1:2]", "3[1:2:3:4]"} {
+		t.Run(q, func(t *testing.T) {
+			_, err := Parse([]string{q}, true)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParse_JSONPath(t *testing.T) {
+	for _, q := range []string{".user.id", ".items[0].sku", ".tags[]", `."a b"`} {
+		t.Run(q, func(t *testing.T) {
+			want, err := column.NewJSONPathSelector(q)
+			assert.NoError(t, err)
+
+			got, err := Parse([]string{q}, true)
+			assert.NoError(t, err)
+			assert.Equal(t, []column.Selector{want}, got)
+		})
+	}
+
+	got, err := Parse([]string{".name|upper", "1"}, true)
+	assert.NoError(t, err)
+	assert.IsType(t, column.PipeSelector{}, got[0])
+	assert.IsType(t, column.IndexSelector{}, got[1])
+
+	for _, q := range []string{".items[", ".a..b"} {
+		t.Run(q, func(t *testing.T) {
+			_, err := Parse([]string{q}, true)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseNamed(t *testing.T) {
+	got, names, err := ParseNamed([]string{"1", "!2", "!3", "name|upper", "!4"}, true)
+	assert.NoError(t, err)
+	assert.Len(t, got, 4)
+	assert.Equal(t, []string{"1", "!2 !3", "name|upper", "!4"}, names)
+
+	_, _, err = ParseNamed([]string{"!=( 1 )"}, true)
+	assert.Error(t, err)
+}
+
+func TestParse_NameWithoutHeader(t *testing.T) {
+	for _, q := range []string{"name", "1,2", "a b", "name:-1", "1:name", "!name", "name|upper", "tags[,]1", "name[1:3]", "@/^a/", "/^a/", "!@/^a/", "@/^a/|upper"} {
+		t.Run(q, func(t *testing.T) {
+			_, err := Parse([]string{q}, false)
+			assert.ErrorContains(t, err, "is invalid query")
+		})
+	}
+
+	for _, q := range []string{"name", "name:-1", "!name", "name|upper", "tags[,]1", "name[1:3]", "@/^a/", "/^a/", "!@/^a/"} {
+		t.Run(q+" with header", func(t *testing.T) {
+			_, err := Parse([]string{q}, true)
+			assert.NoError(t, err)
+		})
+	}
+
+	got, err := Parse([]string{"1", "2:-1", "/a/:/b/", "=( $1 )", ".user.id"}, false)
+	assert.NoError(t, err)
+	assert.Len(t, got, 5)
+}
+
+func TestParse_HeaderRegexp(t *testing.T) {
+	want, err := column.NewHeaderRegexpSelector(`^req`)
+	assert.NoError(t, err)
+
+	for _, q := range []string{"@/^req/", "/^req/"} {
+		got, err := Parse([]string{q}, true)
+		assert.NoError(t, err)
+		assert.Equal(t, []column.Selector{want}, got)
+	}
+
+	got, err := Parse([]string{"/a/:/b/"}, true)
+	assert.NoError(t, err)
+	assert.IsType(t, column.SwitchSelector{}, got[0])
+}
@@ -1,7 +1,9 @@
 package parser
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 )
 
 // Query はクエリ文字列を表すやつ
@@ -17,6 +19,30 @@ var indexQueryValidator = regexp.MustCompile(`^(-?\d*)(:(-?\d*))?(:(-?\d*))?$`)
 // /start regexp/:endIndex
 var switchQueryValidator = regexp.MustCompile(`^(\d+|/.+/):(\+?\d+|/.+/)$`)
 
+// @/regexp/
+// /regexp/ (@ は省略できる)
+var headerRegexpQueryValidator = regexp.MustCompile(`^@?/(.+)/$`)
+
+// name
+// start:stop
+// start:stop:step
+// start, stop はヘッダーのカラム名か index
+var nameQueryValidator = regexp.MustCompile(`^([^:/][^:]*)?(:([^:/][^:]*)?)?(:(-?\d*))?$`)
+
+// =( expression )
+var expressionQueryValidator = regexp.MustCompile(`^=\((.*)\)$`)
+
+// [start:stop:step] のように、カラムの一部を切り出す範囲。先頭に b を付けるとバイト、w を付けると表示幅で数える
+var sliceSpecValidator = regexp.MustCompile(`^([bw]?)(-?\d*)(:(-?\d*))?(:(-?\d*))?$`)
+
+// .user.id
+// .items[0].sku
+// .tags[]
+var jsonPathQueryValidator = regexp.MustCompile(`^\.`)
+
+// !query
+var complementQueryValidator = regexp.MustCompile(`^!.+$`)
+
 func (q Query) isIndexQuery() bool {
 	return indexQueryValidator.MatchString(string(q))
 }
@@ -24,3 +50,152 @@ func (q Query) isIndexQuery() bool {
 func (q Query) isSwitchQuery() bool {
 	return switchQueryValidator.MatchString(string(q))
 }
+
+func (q Query) isHeaderRegexpQuery() bool {
+	return headerRegexpQueryValidator.MatchString(string(q))
+}
+
+func (q Query) isNameQuery() bool {
+	return len(q) != 0 && nameQueryValidator.MatchString(string(q))
+}
+
+func (q Query) isJSONPathQuery() bool {
+	return jsonPathQueryValidator.MatchString(string(q))
+}
+
+func (q Query) isComplementQuery() bool {
+	return complementQueryValidator.MatchString(string(q))
+}
+
+func (q Query) isExpressionQuery() bool {
+	return expressionQueryValidator.MatchString(string(q))
+}
+
+// scanner はクエリを1文字ずつ読んで、/regexp/ や括弧、クォートの中にいるかどうかを追いかけるやつ
+type scanner struct {
+	depth    int
+	quote    rune
+	inRegexp bool
+	escaped  bool
+	prev     rune
+}
+
+// regexpPrefix は直後の / が /regexp/ の始まりになる文字
+const regexpPrefix = ":@!]}."
+
+// step は c を読んで、c が /regexp/ や括弧の外にあるなら true を返す。開き括弧そのものは外にあるとみなす
+// 括弧の中ではクォートされた部分も見る
+func (s *scanner) step(c rune) bool {
+	defer func() { s.prev = c }()
+
+	switch {
+	case s.escaped:
+		s.escaped = false
+		return false
+	case c == '\\' && (s.quote != 0 || s.inRegexp):
+		s.escaped = true
+		return false
+	case s.quote != 0:
+		if c == s.quote {
+			s.quote = 0
+		}
+		return false
+	case s.inRegexp:
+		if c == '/' {
+			s.inRegexp = false
+		}
+		return false
+	case (c == '"' || c == '\'') && s.depth != 0:
+		s.quote = c
+		return false
+	case c == '/' && s.depth == 0 && (s.prev == 0 || strings.ContainsRune(regexpPrefix, s.prev)):
+		s.inRegexp = true
+		return false
+	case c == '(' || c == '[' || c == '{':
+		s.depth++
+		return s.depth == 1
+	case c == ')' || c == ']' || c == '}':
+		s.depth--
+		return false
+	}
+
+	return s.depth == 0
+}
+
+// closed は /regexp/ や括弧、クォートがすべて閉じているかどうか
+func (s *scanner) closed() bool {
+	return s.quote == 0 && !s.inRegexp && s.depth == 0
+}
+
+// splitPipe はクエリを | で分割して、先頭のクエリと残りのパイプに分ける
+// /regexp/ と括弧の中にある | では分割しない
+func (q Query) splitPipe() (Query, []string, error) {
+	var parts []string
+	var sc scanner
+	start := 0
+
+	rs := []rune(string(q))
+	for i, c := range rs {
+		if sc.step(c) && c == '|' {
+			parts = append(parts, string(rs[start:i]))
+			start = i + 1
+		}
+	}
+
+	if !sc.closed() {
+		return "", nil, fmt.Errorf("%s is invalid query", q)
+	}
+
+	parts = append(parts, string(rs[start:]))
+	return Query(parts[0]), parts[1:], nil
+}
+
+// nestedQuery は 4[,]2 や 4{d=","}.2:3 のようなクエリを分解したもの
+type nestedQuery struct {
+	// 括弧の前のクエリ
+	outer Query
+	// 括弧の中身
+	spec string
+	// 括弧の後ろのクエリ
+	inner Query
+	// {} を使っているかどうか
+	brace bool
+}
+
+// splitNested は 4[,]2 や 4{d=","}.2:3 のようなクエリを nestedQuery に分解する。括弧がなければ ok は false になる
+func (q Query) splitNested() (nq nestedQuery, ok bool, err error) {
+	var sc scanner
+	rs := []rune(string(q))
+
+	open := -1
+	for i, c := range rs {
+		top := sc.step(c)
+		if open < 0 {
+			if top && (c == '[' || c == '{') && i != 0 {
+				open = i
+			}
+			continue
+		}
+
+		if sc.depth == 0 {
+			if (rs[open] == '[' && c != ']') || (rs[open] == '{' && c != '}') {
+				return nestedQuery{}, false, fmt.Errorf("%s: mismatched brackets", q)
+			}
+
+			brace := c == '}'
+			rest := string(rs[i+1:])
+			if brace {
+				if !strings.HasPrefix(rest, ".") {
+					return nestedQuery{}, false, fmt.Errorf("%s: '.' is expected after '}'", q)
+				}
+				rest = rest[1:]
+			}
+			return nestedQuery{outer: Query(rs[:open]), spec: string(rs[open+1 : i]), inner: Query(rest), brace: brace}, true, nil
+		}
+	}
+
+	if !sc.closed() {
+		return nestedQuery{}, false, fmt.Errorf("%s is invalid query", q)
+	}
+	return nestedQuery{}, false, nil
+}
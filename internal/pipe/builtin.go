@@ -0,0 +1,156 @@
+package pipe
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+func init() {
+	Register("upper", noArgs(func(s string) (string, error) { return strings.ToUpper(s), nil }))
+	Register("lower", noArgs(func(s string) (string, error) { return strings.ToLower(s), nil }))
+	Register("trim", trim)
+	Register("replace", replace)
+	Register("substr", substr)
+	Register("pad", pad)
+	Register("default", defaultValue)
+	Register("sha256", noArgs(func(s string) (string, error) {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:]), nil
+	}))
+	Register("base64", noArgs(func(s string) (string, error) { return base64.StdEncoding.EncodeToString([]byte(s)), nil }))
+	Register("urlencode", noArgs(func(s string) (string, error) { return url.QueryEscape(s), nil }))
+}
+
+// checkArgs は引数の数が minArgs 以上 maxArgs 以下かどうかを調べる
+func checkArgs(args []string, minArgs, maxArgs int) error {
+	if len(args) < minArgs || len(args) > maxArgs {
+		if minArgs == maxArgs {
+			return fmt.Errorf("takes %d arguments but %d given", minArgs, len(args))
+		}
+		return fmt.Errorf("takes %d to %d arguments but %d given", minArgs, maxArgs, len(args))
+	}
+	return nil
+}
+
+// noArgs は引数をとらない Func を Factory にする
+func noArgs(f Func) Factory {
+	return func(args []string) (Func, error) {
+		if err := checkArgs(args, 0, 0); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+}
+
+// trim は前後の空白を取り除く。trim(cutset) なら cutset に含まれる文字を取り除く
+func trim(args []string) (Func, error) {
+	if err := checkArgs(args, 0, 1); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return func(s string) (string, error) { return strings.TrimSpace(s), nil }, nil
+	}
+	return func(s string) (string, error) { return strings.Trim(s, args[0]), nil }, nil
+}
+
+// replace(old, new) は old をすべて new に置き換える
+func replace(args []string) (Func, error) {
+	if err := checkArgs(args, 2, 2); err != nil {
+		return nil, err
+	}
+	return func(s string) (string, error) { return strings.ReplaceAll(s, args[0], args[1]), nil }, nil
+}
+
+// substr(start, length) は0から数えた start 番目の rune から length 個を取り出す
+// start が負なら末尾から数える。length を省略すると末尾まで
+func substr(args []string) (Func, error) {
+	if err := checkArgs(args, 1, 2); err != nil {
+		return nil, err
+	}
+
+	start, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	length := -1
+	if len(args) == 2 {
+		if length, err = strconv.Atoi(args[1]); err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, fmt.Errorf("length must not be negative")
+		}
+	}
+
+	return func(s string) (string, error) {
+		rs := []rune(s)
+		from := start
+		if from < 0 {
+			from += len(rs)
+		}
+		from = min(max(from, 0), len(rs))
+
+		to := len(rs)
+		if length >= 0 {
+			to = min(from+length, len(rs))
+		}
+		return string(rs[from:to]), nil
+	}, nil
+}
+
+// pad(width, char) は rune 数が width になるまで char で埋める。char を省略すると空白
+// printf の %10s と %-10s と同じで、width が正なら右寄せ、負なら左寄せになる
+func pad(args []string) (Func, error) {
+	if err := checkArgs(args, 1, 2); err != nil {
+		return nil, err
+	}
+
+	width, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	char := " "
+	if len(args) == 2 {
+		if utf8.RuneCountInString(args[1]) != 1 {
+			return nil, fmt.Errorf("pad character must be a single character: '%s'", args[1])
+		}
+		char = args[1]
+	}
+
+	left := width > 0
+	if width < 0 {
+		width = -width
+	}
+
+	return func(s string) (string, error) {
+		n := width - utf8.RuneCountInString(s)
+		if n <= 0 {
+			return s, nil
+		}
+		if left {
+			return strings.Repeat(char, n) + s, nil
+		}
+		return s + strings.Repeat(char, n), nil
+	}, nil
+}
+
+// default(value) は空文字列を value に置き換える
+func defaultValue(args []string) (Func, error) {
+	if err := checkArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	return func(s string) (string, error) {
+		if len(s) == 0 {
+			return args[0], nil
+		}
+		return s, nil
+	}, nil
+}
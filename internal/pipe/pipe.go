@@ -0,0 +1,142 @@
+package pipe
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Func はカラムの値を1つ変換する関数
+type Func func(s string) (string, error)
+
+// Factory はクエリに書かれた引数から Func を作る。引数のチェックはここで行う
+type Factory func(args []string) (Func, error)
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Factory{}
+)
+
+// Register は name という名前で使えるパイプを登録する。同じ名前がすでにあるときは上書きする
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = factory
+}
+
+// Stage はパイプの1段
+type Stage struct {
+	name string
+	args []string
+	f    Func
+}
+
+// NewStage は登録されているパイプから Stage を作る
+func NewStage(name string, args []string) (Stage, error) {
+	mu.RLock()
+	factory, ok := registry[name]
+	mu.RUnlock()
+	if !ok {
+		return Stage{}, fmt.Errorf("unknown pipe '%s'", name)
+	}
+
+	f, err := factory(args)
+	if err != nil {
+		return Stage{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return Stage{name: name, args: args, f: f}, nil
+}
+
+// Apply は s を変換する
+func (s Stage) Apply(v string) (string, error) {
+	rt, err := s.f(v)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", s.name, err)
+	}
+	return rt, nil
+}
+
+func (s Stage) String() string {
+	if s.args == nil {
+		return s.name
+	}
+	return fmt.Sprintf("%s(%s)", s.name, strings.Join(s.args, ","))
+}
+
+// name
+// name(args)
+var stageValidator = regexp.MustCompile(`^(\w+)(\((.*)\))?$`)
+
+// Parse は upper や substr(0,8) のような文字列から Stage を作る
+func Parse(src string) (Stage, error) {
+	m := stageValidator.FindStringSubmatch(strings.TrimSpace(src))
+	if m == nil {
+		return Stage{}, fmt.Errorf("%s is invalid pipe", src)
+	}
+
+	var args []string
+	if len(m[2]) != 0 {
+		var err error
+		args, err = splitArgs(m[3])
+		if err != nil {
+			return Stage{}, fmt.Errorf("%s: %w", src, err)
+		}
+	}
+
+	return NewStage(m[1], args)
+}
+
+// splitArgs は引数をカンマで分割する。"..." か '...' で囲まれた部分のカンマでは分割しない
+// クォートの中では \ で次の文字をエスケープできる。クォートされていない引数は前後の空白を取り除く
+func splitArgs(s string) ([]string, error) {
+	args := []string{}
+	if strings.TrimSpace(s) == "" {
+		return args, nil
+	}
+
+	var b strings.Builder
+	quoted := false
+	var quote rune
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		c := rs[i]
+		switch {
+		case quote != 0 && c == '\\' && i+1 < len(rs):
+			i++
+			b.WriteRune(rs[i])
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			b.WriteRune(c)
+		case c == '"' || c == '\'':
+			// クォートの前の空白は引数に含めない
+			if strings.TrimSpace(b.String()) == "" {
+				b.Reset()
+			}
+			quote = c
+			quoted = true
+		case quoted && unicode.IsSpace(c):
+			// クォートの後の空白も引数に含めない
+		case c == ',':
+			args = append(args, finishArg(b.String(), quoted))
+			b.Reset()
+			quoted = false
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c", quote)
+	}
+	return append(args, finishArg(b.String(), quoted)), nil
+}
+
+func finishArg(s string, quoted bool) string {
+	if quoted {
+		return s
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,110 @@
+package pipe
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		src     string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{src: "upper", input: "abc", want: "ABC"},
+		{src: "lower", input: "ABC", want: "abc"},
+		{src: "trim", input: "  abc ", want: "abc"},
+		{src: "trim(-)", input: "--abc-", want: "abc"},
+		{src: `replace(a, "b,c")`, input: "aXa", want: "b,cXb,c"},
+		{src: `replace(" ", "")`, input: "a b c", want: "abc"},
+		{src: "substr(0,8)", input: "2024-01-02T03:04:05", want: "2024-01-"},
+		{src: "substr(2)", input: "abcdef", want: "cdef"},
+		{src: "substr(-3, 2)", input: "abcdef", want: "de"},
+		{src: "substr(1, 100)", input: "あいう", want: "いう"},
+		{src: "substr(10)", input: "abc", want: ""},
+		{src: "pad(5)", input: "ab", want: "   ab"},
+		{src: "pad(-5, .)", input: "ab", want: "ab..."},
+		{src: "pad(5, 0)", input: "あい", want: "000あい"},
+		{src: "pad(1)", input: "abc", want: "abc"},
+		{src: "default(N/A)", input: "", want: "N/A"},
+		{src: "default(N/A)", input: "x", want: "x"},
+		{src: "sha256", input: "abc", want: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{src: "base64", input: "abc", want: "YWJj"},
+		{src: "urlencode", input: "a b&c", want: "a+b%26c"},
+		{src: "nothing", wantErr: true},
+		{src: "upper(1)", wantErr: true},
+		{src: "substr", wantErr: true},
+		{src: "substr(a)", wantErr: true},
+		{src: "substr(0, -1)", wantErr: true},
+		{src: "pad(1, ab)", wantErr: true},
+		{src: `replace("a, b)`, wantErr: true},
+		{src: "upper(", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			stage, err := Parse(tt.src)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			got, err := stage.Apply(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("test_repeat", func(args []string) (Func, error) {
+		if err := checkArgs(args, 0, 0); err != nil {
+			return nil, err
+		}
+		return func(s string) (string, error) {
+			if s == "" {
+				return "", errors.New("empty")
+			}
+			return strings.Repeat(s, 2), nil
+		}, nil
+	})
+
+	stage, err := Parse("test_repeat")
+	assert.NoError(t, err)
+	assert.Equal(t, "test_repeat", stage.String())
+
+	got, err := stage.Apply("ab")
+	assert.NoError(t, err)
+	assert.Equal(t, "abab", got)
+
+	_, err = stage.Apply("")
+	assert.Error(t, err, "Func のエラーはそのまま返されるべき")
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{src: "", want: []string{}},
+		{src: "a", want: []string{"a"}},
+		{src: " a , b ", want: []string{"a", "b"}},
+		{src: `"a, b" , 'c'`, want: []string{"a, b", "c"}},
+		{src: `" a "`, want: []string{" a "}},
+		{src: `"a\"b"`, want: []string{`a"b`}},
+		{src: `a,,b`, want: []string{"a", "", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got, err := splitArgs(tt.src)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/xztaityozx/sel/internal/option"
+)
+
+// Reader は入力をレコードの区切りで分けて1つずつ読むやつ。区切りは改行、-z の NUL、--record-separator の文字列か正規表現か段落
+// --record-start や --continuation のときは、区切ったレコードのうち続きのものを前のレコードにつなげて1つのレコードにする
+type Reader struct {
+	scanner *bufio.Scanner
+	// start と continuation は --record-start と --continuation。nil なら使わない
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+	join         string
+	// next は次のレコードの先頭として読んでおいたもの。hasNext が false なら空
+	next    string
+	hasNext bool
+}
+
+// initialBufferSize は最初に確保するバッファの大きさ。レコードがこれより長ければ伸ばす
+const initialBufferSize = 64 * 1024
+
+func NewReader(r io.Reader, opt option.RecordOption) *Reader {
+	s := bufio.NewScanner(r)
+	// 1行がとても長い入力も読めるように、バッファの上限はつけない
+	s.Buffer(make([]byte, 0, initialBufferSize), math.MaxInt)
+
+	switch {
+	case opt.Paragraph:
+		s.Split(splitParagraph)
+	case opt.RecordRegexp != nil:
+		s.Split(splitRegexp(opt.RecordRegexp))
+	case len(opt.RecordSeparator) != 0:
+		s.Split(splitLiteral([]byte(opt.RecordSeparator)))
+	default:
+		s.Split(splitLiteral([]byte("\n")))
+	}
+	return &Reader{scanner: s, start: opt.RecordStart, continuation: opt.Continuation, join: opt.RecordJoin}
+}
+
+// Read は次のレコードを区切りを取り除いて返す。もうレコードがなければ io.EOF を返す
+func (r *Reader) Read() (string, error) {
+	if r.start == nil && r.continuation == nil {
+		return r.scan()
+	}
+
+	first := r.next
+	if r.hasNext {
+		r.hasNext = false
+	} else {
+		var err error
+		if first, err = r.scan(); err != nil {
+			return "", err
+		}
+	}
+
+	// 次のレコードの先頭が来るまで、続きのレコードをつなげる
+	// 先頭かどうかは次を読まないとわからないので、読みすぎた1つは next にとっておく
+	var sb strings.Builder
+	sb.WriteString(first)
+	for {
+		rec, err := r.scan()
+		if err == io.EOF {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if r.isStart(rec) {
+			r.next, r.hasNext = rec, true
+			return sb.String(), nil
+		}
+		sb.WriteString(r.join)
+		sb.WriteString(rec)
+	}
+}
+
+// isStart は rec が新しいレコードの先頭かどうかを返す
+// --record-start にマッチして、--continuation にマッチしないものが先頭になる
+func (r *Reader) isStart(rec string) bool {
+	if r.start != nil && !r.start.MatchString(rec) {
+		return false
+	}
+	return r.continuation == nil || !r.continuation.MatchString(rec)
+}
+
+// scan は区切りで分けたレコードを1つ読む
+func (r *Reader) scan() (string, error) {
+	if r.scanner.Scan() {
+		return r.scanner.Text(), nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+// splitLiteral は sep で区切る bufio.SplitFunc を返す。最後のレコードの後ろには sep がなくてもよい
+func splitLiteral(sep []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.Index(data, sep); i >= 0 {
+			return i + len(sep), data[:i], nil
+		}
+		if atEOF && len(data) != 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// splitRegexp は re にマッチしたところで区切る bufio.SplitFunc を返す
+// マッチがバッファの末尾まで続いているときは、もっと長くマッチするかもしれないので続きを読んでから区切る
+func splitRegexp(re *regexp.Regexp) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if loc := re.FindIndex(data); loc != nil && (loc[1] < len(data) || atEOF) {
+			return loc[1], data[:loc[0]], nil
+		}
+		if atEOF && len(data) != 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// splitBlankLines は1行以上の空行で区切る
+var splitBlankLines = splitRegexp(regexp.MustCompile(`\n\n+`))
+
+// splitParagraph は awk の RS="" と同じように、1行以上の空行で区切る bufio.SplitFunc
+// 先頭の空行は読み飛ばして、最後のレコードの末尾の改行は取り除く。改行でもカラムを区切るのは iterator.NewIEnumerable でやる
+func splitParagraph(data []byte, atEOF bool) (int, []byte, error) {
+	if skip := len(data) - len(bytes.TrimLeft(data, "\n")); skip != 0 {
+		return skip, nil, nil
+	}
+
+	advance, token, err := splitBlankLines(data, atEOF)
+	if advance == len(data) && atEOF {
+		token = bytes.TrimRight(token, "\n")
+	}
+	return advance, token, err
+}
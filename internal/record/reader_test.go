@@ -0,0 +1,107 @@
+package record
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xztaityozx/sel/internal/option"
+)
+
+func readAll(t *testing.T, r *Reader) []string {
+	t.Helper()
+	var records []string
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return records
+		}
+		assert.NoError(t, err)
+		records = append(records, rec)
+	}
+}
+
+func TestReader_Read(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opt   option.RecordOption
+		want  []string
+	}{
+		{name: "newline", input: "a b\n\nc\n", want: []string{"a b", "", "c"}},
+		{name: "no newline at the end", input: "a\nb", want: []string{"a", "b"}},
+		{name: "empty", input: "", want: nil},
+		{name: "NUL", input: "./a b\x00./c\nd\x00", opt: option.RecordOption{RecordSeparator: "\x00"}, want: []string{"./a b", "./c\nd"}},
+		{name: "literal", input: "a;;b;;", opt: option.RecordOption{RecordSeparator: ";;"}, want: []string{"a", "b"}},
+		{name: "regexp", input: "a\n---\nb\n-----\nc", opt: option.RecordOption{RecordRegexp: regexp.MustCompile(`\n-+\n`)}, want: []string{"a", "b", "c"}},
+		{name: "paragraph", input: "\n\na 1\nb 2\n\n\n\nc 3\n", opt: option.RecordOption{Paragraph: true}, want: []string{"a 1\nb 2", "c 3"}},
+		{name: "paragraph with trailing blank lines", input: "a\n\nb\n\n\n", opt: option.RecordOption{Paragraph: true}, want: []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, readAll(t, NewReader(strings.NewReader(tt.input), tt.opt)))
+		})
+	}
+}
+
+// oneByteReader は1バイトずつしか返さない。区切りがバッファの境目をまたいでも正しく読めるか確かめるのに使う
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (o oneByteReader) Read(p []byte) (int, error) {
+	return o.r.Read(p[:1])
+}
+
+func TestReader_Read_SplitAcrossReads(t *testing.T) {
+	r := NewReader(oneByteReader{strings.NewReader("a\n---\nb\n-----\nc")}, option.RecordOption{RecordRegexp: regexp.MustCompile(`\n-+\n`)})
+	assert.Equal(t, []string{"a", "b", "c"}, readAll(t, r))
+
+	r = NewReader(oneByteReader{strings.NewReader("a\n\n\n\nb\n")}, option.RecordOption{Paragraph: true})
+	assert.Equal(t, []string{"a", "b"}, readAll(t, r))
+}
+
+func TestReader_Read_LongRecord(t *testing.T) {
+	long := strings.Repeat("x", initialBufferSize*3)
+	assert.Equal(t, []string{long, "y"}, readAll(t, NewReader(strings.NewReader(long+"\ny"), option.RecordOption{})))
+}
+
+func TestReader_Read_Multiline(t *testing.T) {
+	input := "Exception in thread main\n2024-01-01 ERROR boom\n  at a.b(C.java:1)\n  at d.e(F.java:2)\n2024-01-02 INFO ok\n2024-01-03 WARN slow\n  retrying"
+	start := regexp.MustCompile(`^\d{4}-`)
+	continuation := regexp.MustCompile(`^\s+`)
+	tests := []struct {
+		name string
+		opt  option.RecordOption
+		want []string
+	}{
+		{
+			name: "--record-start",
+			opt:  option.RecordOption{RecordStart: start, RecordJoin: "\n"},
+			want: []string{"Exception in thread main", "2024-01-01 ERROR boom\n  at a.b(C.java:1)\n  at d.e(F.java:2)", "2024-01-02 INFO ok", "2024-01-03 WARN slow\n  retrying"},
+		},
+		{
+			name: "--continuation",
+			opt:  option.RecordOption{Continuation: continuation, RecordJoin: " | "},
+			want: []string{"Exception in thread main", "2024-01-01 ERROR boom |   at a.b(C.java:1) |   at d.e(F.java:2)", "2024-01-02 INFO ok", "2024-01-03 WARN slow |   retrying"},
+		},
+		{
+			name: "both",
+			opt:  option.RecordOption{RecordStart: regexp.MustCompile(`^(\d{4}-|Exception)`), Continuation: regexp.MustCompile(`^\s+at `), RecordJoin: ""},
+			want: []string{"Exception in thread main", "2024-01-01 ERROR boom  at a.b(C.java:1)  at d.e(F.java:2)", "2024-01-02 INFO ok", "2024-01-03 WARN slow  retrying"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, readAll(t, NewReader(strings.NewReader(input), tt.opt)))
+		})
+	}
+
+	// -z で区切ったレコードもつなげられる
+	r := NewReader(strings.NewReader("a\x00 b\x00c"), option.RecordOption{RecordSeparator: "\x00", Continuation: continuation, RecordJoin: ","})
+	assert.Equal(t, []string{"a, b", "c"}, readAll(t, r))
+}
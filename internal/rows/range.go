@@ -0,0 +1,90 @@
+package rows
+
+// rangeSelector は start:stop:step で行を選ぶやつ
+// 負の index は末尾から数える。末尾からの位置は最後まで読まないと決まらないので、必要な分だけの行を ring に溜めておく
+type rangeSelector struct {
+	start     int
+	step      int
+	stop      int
+	isInfStop bool
+
+	// 今までに読んだ行数
+	n int
+	// 出力するかどうかが決まっていない行。start か stop が負のときだけ使う
+	pending *ring
+}
+
+func newRange(start, step, stop int, isInfStop bool) *rangeSelector {
+	r := &rangeSelector{start: start, step: step, stop: stop, isInfStop: isInfStop}
+	if start < 0 {
+		// -100: のようなときは末尾の |start| 行だけ覚えておけばよい
+		r.pending = newRing(-start)
+	} else if !isInfStop && stop < 0 {
+		// 10:-5 のようなときは、後ろに |stop|-1 行続くことがわかるまで待つ
+		r.pending = newRing(-stop)
+	}
+	return r
+}
+
+func (r *rangeSelector) Push(rec Record) []Record {
+	r.n++
+
+	if r.start < 0 {
+		r.pending.push(indexedRecord{index: r.n, Record: rec})
+		return nil
+	}
+
+	selected := r.n >= r.start && (r.stop < 0 || r.isInfStop || r.n <= r.stop) && (r.n-r.start)%r.step == 0
+	if r.pending == nil {
+		if selected {
+			return []Record{rec}
+		}
+		return nil
+	}
+
+	// 選ばれなかった行でも、溜めている行の後ろに続く行にはなる
+	if selected {
+		r.pending.push(indexedRecord{index: r.n, Record: rec})
+	}
+	var rt []Record
+	for p, ok := r.pending.front(); ok && p.index <= r.n+r.stop+1; p, ok = r.pending.front() {
+		rt = append(rt, p.Record)
+		r.pending.pop()
+	}
+	return rt
+}
+
+func (r *rangeSelector) Flush() []Record {
+	if r.start > 0 {
+		// 残っているのは stop より後ろの行なので選ばれない
+		return nil
+	}
+
+	start := r.n + r.start + 1
+	stop := r.n
+	if !r.isInfStop {
+		stop = r.stop
+		if stop < 0 {
+			stop = r.n + stop + 1
+		}
+	}
+
+	var rt []Record
+	for _, p := range r.pending.items() {
+		if start <= p.index && p.index <= stop && (p.index-start)%r.step == 0 {
+			rt = append(rt, p.Record)
+		}
+	}
+	return rt
+}
+
+func (r *rangeSelector) Done() bool {
+	return r.start > 0 && r.stop > 0 && r.n >= r.stop
+}
+
+func (r *rangeSelector) Reset() {
+	r.n = 0
+	if r.pending != nil {
+		r.pending.reset()
+	}
+}
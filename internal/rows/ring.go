@@ -0,0 +1,61 @@
+package rows
+
+// indexedRecord は何行目かを覚えている Record
+type indexedRecord struct {
+	index int
+	Record
+}
+
+// ring は決まった数の行だけを覚えておくリングバッファ。いっぱいのときに push すると一番古い行を捨てる
+type ring struct {
+	buf  []indexedRecord
+	head int
+	size int
+}
+
+func newRing(capacity int) *ring {
+	return &ring{buf: make([]indexedRecord, capacity)}
+}
+
+func (r *ring) push(v indexedRecord) {
+	if r.size == len(r.buf) {
+		r.buf[r.head] = v
+		r.head = (r.head + 1) % len(r.buf)
+		return
+	}
+	r.buf[(r.head+r.size)%len(r.buf)] = v
+	r.size++
+}
+
+// front は一番古い行を返す
+func (r *ring) front() (indexedRecord, bool) {
+	if r.size == 0 {
+		return indexedRecord{}, false
+	}
+	return r.buf[r.head], true
+}
+
+// pop は一番古い行を捨てる
+func (r *ring) pop() {
+	if r.size == 0 {
+		return
+	}
+	r.buf[r.head] = indexedRecord{}
+	r.head = (r.head + 1) % len(r.buf)
+	r.size--
+}
+
+// items は古い順にすべての行を返す
+func (r *ring) items() []indexedRecord {
+	rt := make([]indexedRecord, 0, r.size)
+	for i := range r.size {
+		rt = append(rt, r.buf[(r.head+i)%len(r.buf)])
+	}
+	return rt
+}
+
+func (r *ring) reset() {
+	clear(r.buf)
+	r.head = 0
+	r.size = 0
+}
@@ -0,0 +1,96 @@
+package rows
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Record は入力の1行分
+type Record struct {
+	// 分割する前の行。/regexp/ とのマッチに使う
+	Line string
+	// CSV/TSV のときはパース済みのカラム。それ以外は nil
+	Fields []string
+}
+
+// Selector は --rows で指定された行を選ぶやつ。行は 1-indexed で、入力ファイルごとに数える
+type Selector interface {
+	// Push は次の行を渡して、出力してよいことが確定した行を返す
+	Push(r Record) []Record
+	// Flush は入力の終わりで呼んで、最後まで読まないと決まらなかった行を返す
+	Flush() []Record
+	// Done はこれ以上どの行も選ばれないなら true を返す。残りの入力は読まなくてよい
+	Done() bool
+	// Reset は次の入力ファイルのために状態を捨てる
+	Reset()
+}
+
+// start:stop:step
+// start:stop
+// start
+var rangeValidator = regexp.MustCompile(`^(-?\d*)(:(-?\d*))?(:(-?\d*))?$`)
+
+// startIndex:/end regexp/
+// /start regexp/:endIndex
+// /start regexp/:+N
+var switchValidator = regexp.MustCompile(`^(\d+|/.+/):(\+?\d+|/.+/)$`)
+
+// NewSelector は --rows の値をパースして Selector を返す。文法はカラムのクエリと同じ
+//
+//	10:20           10行目から20行目
+//	::2             奇数行
+//	-100:           末尾の100行
+//	/^BEGIN/:/^END/ BEGIN から END まで
+func NewSelector(spec string) (Selector, error) {
+	if len(spec) != 0 && rangeValidator.MatchString(spec) {
+		return newRangeSelector(spec)
+	}
+	if m := switchValidator.FindStringSubmatch(spec); m != nil {
+		return newSwitchSelector(m[1], m[2])
+	}
+	return nil, fmt.Errorf("%s is invalid rows", spec)
+}
+
+func newRangeSelector(spec string) (Selector, error) {
+	section := strings.Split(spec, ":")
+	if len(section) == 1 {
+		idx, err := strconv.Atoi(section[0])
+		if err != nil {
+			return nil, err
+		}
+		if idx == 0 {
+			return nil, fmt.Errorf("%s: row starts from 1", spec)
+		}
+		return newRange(idx, 1, idx, false), nil
+	}
+
+	start, step, stop, isInfStop := 1, 1, 0, true
+	var err error
+	if len(section[0]) != 0 {
+		if start, err = strconv.Atoi(section[0]); err != nil {
+			return nil, err
+		}
+	}
+	if len(section[1]) != 0 {
+		if stop, err = strconv.Atoi(section[1]); err != nil {
+			return nil, err
+		}
+		isInfStop = false
+	}
+	if len(section) == 3 && len(section[2]) != 0 {
+		if step, err = strconv.Atoi(section[2]); err != nil {
+			return nil, err
+		}
+	}
+
+	if start == 0 || (stop == 0 && !isInfStop) {
+		return nil, fmt.Errorf("%s: row starts from 1", spec)
+	}
+	if step <= 0 {
+		// 行は前から順に読むので、逆順にはできない
+		return nil, fmt.Errorf("%s: step must be bigger than 0", spec)
+	}
+	return newRange(start, step, stop, isInfStop), nil
+}
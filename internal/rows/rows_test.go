@@ -0,0 +1,134 @@
+package rows
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// selectLines は 1 から n までの行を Selector に流して、選ばれた行を返す
+func selectLines(t *testing.T, s Selector, n int) []string {
+	t.Helper()
+
+	var rt []string
+	for i := 1; i <= n; i++ {
+		for _, r := range s.Push(Record{Line: strconv.Itoa(i)}) {
+			rt = append(rt, r.Line)
+		}
+	}
+	for _, r := range s.Flush() {
+		rt = append(rt, r.Line)
+	}
+	return rt
+}
+
+func TestNewSelector(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{spec: "3", want: []string{"3"}},
+		{spec: "-3", want: []string{"8"}},
+		{spec: "3:5", want: []string{"3", "4", "5"}},
+		{spec: "8:", want: []string{"8", "9", "10"}},
+		{spec: ":2", want: []string{"1", "2"}},
+		{spec: "::3", want: []string{"1", "4", "7", "10"}},
+		{spec: "2:7:2", want: []string{"2", "4", "6"}},
+		{spec: "-3:", want: []string{"8", "9", "10"}},
+		{spec: "-5::2", want: []string{"6", "8", "10"}},
+		{spec: "-5:7", want: []string{"6", "7"}},
+		{spec: "-5:-3", want: []string{"6", "7", "8"}},
+		{spec: "7:-2", want: []string{"7", "8", "9"}},
+		{spec: "2:-2:3", want: []string{"2", "5", "8"}},
+		{spec: "-100:", want: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}},
+		{spec: "20:", want: nil},
+		{spec: "-20", want: nil},
+		{spec: "3:/^5$/", want: []string{"3", "4", "5"}},
+		{spec: "/^[27]$/:/^[38]$/", want: []string{"2", "3", "7", "8"}},
+		{spec: "/^[27]$/:+2", want: []string{"2", "3", "4", "7", "8", "9"}},
+		{spec: "/^9$/:/^100$/", want: []string{"9", "10"}},
+		{spec: "/^5$/:3", want: []string{"5"}},
+		{spec: "/^5$/:6", want: []string{"5", "6"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := NewSelector(tt.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, selectLines(t, s, 10))
+
+			s.Reset()
+			assert.Equal(t, tt.want, selectLines(t, s, 10), "Reset したら同じ結果になるべき")
+		})
+	}
+
+	for _, spec := range []string{"", "0", "0:3", "1:0", "::0", "::-1", "a:b", "1:2:3:4", "/(/:3"} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := NewSelector(spec)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSelector_Done(t *testing.T) {
+	tests := []struct {
+		spec string
+		// 何行読んだら Done になるか。0 なら Done にならない
+		want int
+	}{
+		{spec: "3", want: 3},
+		{spec: "2:5", want: 5},
+		{spec: "2:", want: 0},
+		{spec: "-3:5", want: 0},
+		{spec: "2:-1", want: 0},
+		{spec: "3:/^5$/", want: 5},
+		{spec: "/^3$/:5", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := NewSelector(tt.spec)
+			assert.NoError(t, err)
+
+			got := 0
+			for i := 1; i <= 10; i++ {
+				s.Push(Record{Line: strconv.Itoa(i)})
+				if s.Done() {
+					got = i
+					break
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRing(t *testing.T) {
+	r := newRing(3)
+	for i := 1; i <= 5; i++ {
+		r.push(indexedRecord{index: i})
+	}
+
+	var got []int
+	for _, v := range r.items() {
+		got = append(got, v.index)
+	}
+	assert.Equal(t, []int{3, 4, 5}, got, "古いものから捨てられるべき")
+
+	f, ok := r.front()
+	assert.True(t, ok)
+	assert.Equal(t, 3, f.index)
+
+	r.pop()
+	r.push(indexedRecord{index: 6})
+	got = nil
+	for _, v := range r.items() {
+		got = append(got, v.index)
+	}
+	assert.Equal(t, []int{4, 5, 6}, got)
+
+	r.reset()
+	_, ok = r.front()
+	assert.False(t, ok)
+}
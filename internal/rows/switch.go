@@ -0,0 +1,101 @@
+package rows
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// address は行番号か /regexp/ で行を指定するやつ
+type address struct {
+	regexp *regexp.Regexp
+	num    int
+}
+
+func newAddress(q string) (address, error) {
+	if strings.HasPrefix(q, "/") {
+		r, err := regexp.Compile(q[1 : len(q)-1])
+		return address{regexp: r}, err
+	}
+	num, err := strconv.Atoi(q)
+	return address{num: num}, err
+}
+
+func (a address) match(rec Record, n int) bool {
+	if a.regexp == nil {
+		return a.num == n
+	}
+	return a.regexp.MatchString(rec.Line)
+}
+
+// switchSelector は sed の 2addr と同じように、begin にマッチした行から end にマッチした行までを選ぶやつ
+// end に +N を指定すると、begin にマッチした行とその後ろの N 行を選ぶ
+type switchSelector struct {
+	begin address
+	end   address
+	// +N のときの N。-1 なら end は address
+	after int
+
+	n int
+	// 選択中かどうか
+	on bool
+	// +N のときの残りの行数
+	rest int
+}
+
+func newSwitchSelector(begin, end string) (*switchSelector, error) {
+	b, err := newAddress(begin)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(end, "+") {
+		after, err := strconv.Atoi(end[1:])
+		return &switchSelector{begin: b, after: after}, err
+	}
+
+	e, err := newAddress(end)
+	return &switchSelector{begin: b, end: e, after: -1}, err
+}
+
+func (s *switchSelector) Push(rec Record) []Record {
+	s.n++
+
+	if s.on {
+		if s.after < 0 {
+			s.on = !s.end.match(rec, s.n)
+		} else {
+			s.rest--
+			s.on = s.rest > 0
+		}
+		return []Record{rec}
+	}
+
+	if !s.begin.match(rec, s.n) {
+		return nil
+	}
+
+	if s.after < 0 {
+		// sed と同じで、begin にマッチした行では end を見ない。ただし行番号の end が begin 以前なら1行だけ
+		s.on = s.end.regexp != nil || s.end.num > s.n
+	} else {
+		s.rest = s.after
+		s.on = s.rest > 0
+	}
+	return []Record{rec}
+}
+
+func (s *switchSelector) Flush() []Record {
+	return nil
+}
+
+func (s *switchSelector) Done() bool {
+	// 行番号で始まる範囲は一度しか選ばれない
+	return s.begin.regexp == nil && s.n >= s.begin.num && !s.on
+}
+
+func (s *switchSelector) Reset() {
+	s.n = 0
+	s.on = false
+	s.rest = 0
+}
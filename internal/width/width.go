@@ -0,0 +1,30 @@
+package width
+
+import (
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// RuneWidth は r を端末に表示したときの幅を返す
+// 全角と East Asian Wide な文字は2、結合文字や制御文字は0、それ以外は1になる。Ambiguous な文字は1として扱う
+func RuneWidth(r rune) int {
+	if r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r) || unicode.IsControl(r) {
+		return 0
+	}
+
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// StringWidth は s を端末に表示したときの幅を返す
+func StringWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += RuneWidth(r)
+	}
+	return w
+}
@@ -0,0 +1,38 @@
+package width
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuneWidth(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want int
+	}{
+		{r: 'a', want: 1},
+		{r: 'ｱ', want: 1},
+		{r: 'あ', want: 2},
+		{r: '漢', want: 2},
+		{r: 'Ａ', want: 2},
+		{r: '\u0301', want: 0},
+		{r: '\u200b', want: 0},
+		{r: '\t', want: 0},
+		{r: '°', want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.r), func(t *testing.T) {
+			assert.Equal(t, tt.want, RuneWidth(tt.r))
+		})
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	assert.Equal(t, 0, StringWidth(""))
+	assert.Equal(t, 5, StringWidth("hello"))
+	assert.Equal(t, 10, StringWidth("こんにちは"))
+	assert.Equal(t, 5, StringWidth("abcあ"))
+	assert.Equal(t, 3, StringWidth("e\u0301漢"))
+}
@@ -1,13 +1,19 @@
 package test
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func runSel(sel string, args, stdin []string) (stdout, stderr []string, err error) {
@@ -366,15 +372,53 @@ func Test_E2E(t *testing.T) {
 			expectedError:  nil,
 		},
 		{
-			name: "sel --csv 2 prints 1,2,3",
+			name: "sel --csv 2 prints \"1,2,3\" quoted as CSV",
 			input: input{
 				args:  []string{"--csv", "2"},
 				stdin: []string{"1,\"1,2,3\",3"},
 			},
-			expectedStdout: []string{"1,2,3"},
+			expectedStdout: []string{`"1,2,3"`},
 			expectedStderr: []string{""},
 			expectedError:  nil,
 		},
+		{
+			name: "sel --csv -D ' ' 2 1 prints with the output delimiter",
+			input: input{
+				args:  []string{"--csv", "-D", " ", "2", "1"},
+				stdin: []string{"1,\"1,2,3\",3"},
+			},
+			expectedStdout: []string{"1,2,3 1"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --output-csv --output-quote non-numeric 1 2 3 quotes non-numeric columns",
+			input: input{
+				args:  []string{"--output-csv", "--output-quote", "non-numeric", "1", "2", "3"},
+				stdin: []string{`10 "x" -1.5`},
+			},
+			expectedStdout: []string{`10,"""x""",-1.5`},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --output-tsv --output-quote all --crlf 1 2 ends lines with CRLF",
+			input: input{
+				args:  []string{"--output-tsv", "--output-quote", "all", "--crlf", "1", "2"},
+				stdin: []string{"a b", "c d"},
+			},
+			expectedStdout: []string{"\"a\"\t\"b\"\r", "\"c\"\t\"d\"\r"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --output-csv -D , exits with error",
+			input: input{
+				args:  []string{"--output-csv", "-D", ",", "1"},
+				stdin: []string{"a b"},
+			},
+			expectExitError: true,
+		},
 		{
 			name: "sel 1 2 3 prints 1  2",
 			input: input{
@@ -416,12 +460,12 @@ func Test_E2E(t *testing.T) {
 			expectedError:  nil,
 		},
 		{
-			name: "sel --tsv 1 2 prints 1 2\t3\t4",
+			name: "sel --tsv 1 2 prints 1\t\"2\t3\t4\" as TSV",
 			input: input{
 				args:  []string{"--tsv", "1", "2"},
 				stdin: []string{"1\t\"2\t3\t4\"\t5"},
 			},
-			expectedStdout: []string{"1 2\t3\t4"},
+			expectedStdout: []string{"1\t\"2\t3\t4\""},
 			expectedStderr: []string{""},
 			expectedError:  nil,
 		},
@@ -625,20 +669,970 @@ func Test_E2E(t *testing.T) {
 			expectedStderr: []string{""},
 			expectedError:  nil,
 		},
-	}
-
-	for _, testcase := range testcases {
-		t.Run(testcase.name, func(t *testing.T) {
-			stdout, stderr, err := runSel(selPath, testcase.input.args, testcase.input.stdin)
-			if testcase.expectExitError {
-				as.Error(err, "エラーで終了するべき")
-			} else if testcase.expectedError != nil {
-				as.Equal(err, testcase.expectedError, "エラー内容が一致するべき")
-			} else {
-				as.NoError(err, "エラーなしで終了するべき")
-				as.Equal(testcase.expectedStdout, stdout, "標準出力が一致するべき")
-				as.Equal(testcase.expectedStderr, stderr, "標準エラー出力が一致するべき")
-			}
-		})
-	}
+		{
+			name: "sel '!3' '!7' prints all columns except 3 and 7",
+			input: input{
+				args:  []string{"!3", "!7"},
+				stdin: []string{"1 2 3 4 5 6 7 8", "1 2 3 4 5"},
+			},
+			expectedStdout: []string{"1 2 4 5 6 8", "1 2 4 5"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel '!2:3' 1 prints complement and then 1",
+			input: input{
+				args:  []string{"!2:3", "1"},
+				stdin: []string{"a b c d", "e f g h i"},
+			},
+			expectedStdout: []string{"a d a", "e h i e"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel '!4:' excludes nothing past the end of short lines",
+			input: input{
+				args:  []string{"!4:"},
+				stdin: []string{"a", "a b c", "a b c d e"},
+			},
+			expectedStdout: []string{"a", "a b c", "a b c"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel '!2:10' clamps the range to short lines",
+			input: input{
+				args:  []string{"!2:10"},
+				stdin: []string{"a", "a b c"},
+			},
+			expectedStdout: []string{"a", "a"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --header '!name' prints all columns except name",
+			input: input{
+				args:  []string{"--header", "!name"},
+				stdin: []string{"id name age", "1 alice 20"},
+			},
+			expectedStdout: []string{"1 20"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --where '$3 =~ /ERROR/' 1 prints only error lines",
+			input: input{
+				args:  []string{"--where", "$3 =~ /ERROR/", "1"},
+				stdin: []string{"1 app ERROR", "2 app INFO", "3 db ERROR"},
+			},
+			expectedStdout: []string{"1", "3"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --where '3 =~ /ERROR/' 1 reads bare 3 as column 3",
+			input: input{
+				args:  []string{"--where", "3 =~ /ERROR/", "1"},
+				stdin: []string{"a b ERROR", "c d INFO"},
+			},
+			expectedStdout: []string{"a"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --where '5 > 100' 1 compares column 5 with 100",
+			input: input{
+				args:  []string{"--where", "5 > 100", "1"},
+				stdin: []string{"a x x x 200", "b x x x 99", "c x x x 1000"},
+			},
+			expectedStdout: []string{"a", "c"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --csv --header --where 'status == \"ok\" && -1 != \"\"' name reads bare names and -1 as columns",
+			input: input{
+				args:  []string{"--csv", "--header", "--where", `status == "ok" && -1 != ""`, "name"},
+				stdin: []string{"name,status,note", "alice,ok,memo", "bob,ng,memo", "carol,ok,"},
+			},
+			expectedStdout: []string{"alice"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --where '$2 > 100' --where '$3 == \"a,b\"' 1 ands conditions",
+			input: input{
+				args:  []string{"--where", "$2 > 100", "--where", `$3 == "a,b"`, "1"},
+				stdin: []string{"x 200 a,b", "y 50 a,b", "z 300 c"},
+			},
+			expectedStdout: []string{"x"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --invert-where --where '$3 =~ /ERROR/' 1 prints non error lines",
+			input: input{
+				args:  []string{"--invert-where", "--where", "$3 =~ /ERROR/", "1"},
+				stdin: []string{"1 app ERROR", "2 app INFO", "3 db ERROR"},
+			},
+			expectedStdout: []string{"2"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --csv --header --where '$status == \"ok\"' name",
+			input: input{
+				args:  []string{"--csv", "--header", "--where", `$status == "ok"`, "name"},
+				stdin: []string{"id,name,status", "1,alice,ok", "2,bob,ng"},
+			},
+			expectedStdout: []string{"alice"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel -M --where '$3 == \"\"' 1 treats missing column as empty",
+			input: input{
+				args:  []string{"-M", "--where", `$3 == ""`, "1"},
+				stdin: []string{"a b c", "d e"},
+			},
+			expectedStdout: []string{"d"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --where '$3 =~ /ERROR/' 1 skips lines without column 3",
+			input: input{
+				args:  []string{"--where", "$3 =~ /ERROR/", "1"},
+				stdin: []string{"10:00 app ERROR: a", "10:01", "10:02 app", "10:03 app ERROR: b"},
+			},
+			expectedStdout: []string{"10:00", "10:03"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --invert-where --where '$3 =~ /ERROR/' 1 prints lines without column 3",
+			input: input{
+				args:  []string{"--invert-where", "--where", "$3 =~ /ERROR/", "1"},
+				stdin: []string{"10:00 app ERROR: a", "10:01", "10:02 app INFO: c"},
+			},
+			expectedStdout: []string{"10:01", "10:02"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel 1 '=( $2 * 1000 )' '=( $1 . \"-\" . $3 )' '=( len($3) )' prints computed columns",
+			input: input{
+				args:  []string{"1", "=( $2 * 1000 )", `=( $1 . "-" . $3 )`, "=( len($3) )"},
+				stdin: []string{"a 1.5 xyz", "b 2 ab"},
+			},
+			expectedStdout: []string{"a 1500 a-xyz 3", "b 2000 b-ab 2"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel '=( $2 * 2 )' with non number exits with error",
+			input: input{
+				args:  []string{"=( $2 * 2 )"},
+				stdin: []string{"a b"},
+			},
+			expectExitError: true,
+		},
+		{
+			name: "sel -E N/A 1 '=( $2 * 2 )' fills non number",
+			input: input{
+				args:  []string{"-E", "N/A", "1", "=( $2 * 2 )"},
+				stdin: []string{"a b", "c 3"},
+			},
+			expectedStdout: []string{"a N/A", "c 6"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --header '=( $price * $qty )' resolves names",
+			input: input{
+				args:  []string{"--header", "=( $price * $qty )"},
+				stdin: []string{"item price qty", "apple 100 3"},
+			},
+			expectedStdout: []string{"300"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel '1|upper' '2|trim|substr(0,3)|default(N/A)' 3 transforms columns",
+			input: input{
+				args:  []string{"-d", ",", "1|upper", "2|trim|substr(0,3)|default(N/A)", "3"},
+				stdin: []string{"abc, 2024-01-01 ,x", "def,,y"},
+			},
+			expectedStdout: []string{"ABC 202 x", "DEF N/A y"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel '1:2|pad(3,0)' pads all selected columns",
+			input: input{
+				args:  []string{"1:2|pad(3,0)"},
+				stdin: []string{"1 22 333"},
+			},
+			expectedStdout: []string{"001 022"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel '1|unknown' exits with error",
+			input: input{
+				args:  []string{"1|unknown"},
+				stdin: []string{"a"},
+			},
+			expectExitError: true,
+		},
+		{
+			name: "sel '2[,]2' '2{d=\",\"}.2:3' prints sub-fields",
+			input: input{
+				args:  []string{"2[,]2", `2{d=","}.2:3`},
+				stdin: []string{"a b,c,d e", "f g,h,i j"},
+			},
+			expectedStdout: []string{"c c d", "h h i"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel '1[/\\s*;\\s*/]2[=]2|upper' splits by regexp recursively",
+			input: input{
+				args:  []string{"-d", ",", `1[/\s*;\s*/]2[=]2|upper`},
+				stdin: []string{"a=1 ; b=2", "c=3;d=4"},
+			},
+			expectedStdout: []string{"2", "4"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --header 'tags[,]-1' resolves outer query by header",
+			input: input{
+				args:  []string{"--header", "tags[,]-1"},
+				stdin: []string{"id tags", "1 x,y,z"},
+			},
+			expectedStdout: []string{"z"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel '1[,]' exits with error",
+			input: input{
+				args:  []string{"1[,]"},
+				stdin: []string{"a,b"},
+			},
+			expectExitError: true,
+		},
+		{
+			name: "sel '2[1:10]' '2[-8:]' '1[::-1]' cuts characters out of columns",
+			input: input{
+				args:  []string{"2[1:10]", "2[-8:]", "1[::-1]"},
+				stdin: []string{"abc 2024-01-02T03:04:05", "xy 2025-12-31T23:59:59"},
+			},
+			expectedStdout: []string{"2024-01-02 03:04:05 cba", "2025-12-31 23:59:59 yx"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel '0[3:7]' '1[b1:3]' '1[w1:4]' cuts line, bytes and display width",
+			input: input{
+				args:  []string{"0[3:7]", "1[b1:3]", "1[w1:4]"},
+				stdin: []string{"日本語テキスト abc"},
+			},
+			expectedStdout: []string{"語テキスト 日 日本"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel -E - '1[5]' fills out-of-range character",
+			input: input{
+				args:  []string{"-E", "-", "1[5]"},
+				stdin: []string{"abcdef", "abc"},
+			},
+			expectedStdout: []string{"e", "-"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --rows 2:4 1 prints only lines 2 to 4",
+			input: input{
+				args:  []string{"--rows", "2:4", "1"},
+				stdin: []string{"a 1", "b 2", "c 3", "d 4", "e 5"},
+			},
+			expectedStdout: []string{"b", "c", "d"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --rows -2: 2 prints last 2 lines",
+			input: input{
+				args:  []string{"--rows", "-2:", "2"},
+				stdin: []string{"a 1", "b 2", "c 3", "d 4", "e 5"},
+			},
+			expectedStdout: []string{"4", "5"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --rows '::2' --where '$2 > 1' 1 applies rows before where",
+			input: input{
+				args:  []string{"--rows", "::2", "--where", "$2 > 1", "1"},
+				stdin: []string{"a 1", "b 2", "c 3", "d 4", "e 5"},
+			},
+			expectedStdout: []string{"c", "e"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --csv --header --rows '/^b/:+1' name counts rows after header",
+			input: input{
+				args:  []string{"--csv", "--header", "--rows", "/^b/:+1", "name"},
+				stdin: []string{"id,name", "a,alice", "b,bob", "c,carol", "d,dave"},
+			},
+			expectedStdout: []string{"bob", "carol"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --rows 0 exits with error",
+			input: input{
+				args:  []string{"--rows", "0", "1"},
+				stdin: []string{"a"},
+			},
+			expectExitError: true,
+		},
+		{
+			name: "sel --widths 3,5,- 2 -1 splits by widths",
+			input: input{
+				args:  []string{"--widths", "3,5,-", "--", "2", "-1"},
+				stdin: []string{"001alice tokyo", "002bob   osaka"},
+			},
+			expectedStdout: []string{"alice  tokyo", "bob    osaka"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --cuts 1,4,10 '2|trim' 1 --where '$3 == \"osaka\"' splits by positions",
+			input: input{
+				args:  []string{"--cuts", "1,4,10", "--where", `$3 == "osaka"`, "2|trim", "1"},
+				stdin: []string{"001alice tokyo", "002bob   osaka"},
+			},
+			expectedStdout: []string{"bob 002"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --display-width --widths 4,2,- 1 3 counts display width",
+			input: input{
+				args:  []string{"--display-width", "--widths", "4,2,-", "1", "3"},
+				stdin: []string{"日本語abc", "abcdefgh"},
+			},
+			expectedStdout: []string{"日本 abc", "abcd gh"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --widths 3,-,2 exits with error",
+			input: input{
+				args:  []string{"--widths", "3,-,2", "1"},
+				stdin: []string{"abcdef"},
+			},
+			expectExitError: true,
+		},
+		{
+			name: "sel --aligned NAMES STATUS splits by header positions",
+			input: input{
+				args: []string{"--aligned", "NAMES", "STATUS"},
+				stdin: []string{
+					"CONTAINER ID   IMAGE     STATUS       NAMES",
+					"4c01db0b339c   nginx     Up 3 hours   web",
+					"d7886598dbe2   redis     Up 2 days    cache",
+				},
+			},
+			expectedStdout: []string{"web Up 3 hours", "cache Up 2 days"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --aligned --aligned-sample 2 -D , PID TIME CMD refines positions by rows",
+			input: input{
+				args: []string{"--aligned", "--aligned-sample", "2", "-D", ",", "PID", "TIME", "CMD"},
+				stdin: []string{
+					"  PID TTY          TIME CMD",
+					"    1 pts/0    00:00:00 bash",
+					"12345 pts/0    00:00:01 sel",
+					"  678 pts/1    00:01:00 vim",
+				},
+			},
+			expectedStdout: []string{"1,00:00:00,bash", "12345,00:00:01,sel", "678,00:01:00,vim"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --jsonl .user.id .items[0].sku .tags[] selects by JSON path",
+			input: input{
+				args: []string{"--jsonl", ".user.id", ".items[0].sku", ".tags[]"},
+				stdin: []string{
+					`{"user":{"id":1},"items":[{"sku":"A1"}],"tags":["x","y"]}`,
+					`{"user":{"id":2},"items":[{"sku":"B2"},{"sku":"C3"}],"tags":[]}`,
+				},
+			},
+			expectedStdout: []string{"1 A1 x y", "2 B2"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --jsonl 2 -1 selects top-level values in key order",
+			input: input{
+				args:  []string{"--jsonl", "--", "2", "-1"},
+				stdin: []string{`{"id":1,"name":"alice","tags":["a"]}`},
+			},
+			expectedStdout: []string{`alice ["a"]`},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --jsonl -E - .name .age fills missing path",
+			input: input{
+				args:  []string{"--jsonl", "-E", "-", ".name", ".age"},
+				stdin: []string{`{"name":"alice","age":20}`, `{"name":"bob"}`},
+			},
+			expectedStdout: []string{"alice 20", "bob -"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --jsonl --output-format json a b keeps keys",
+			input: input{
+				args:  []string{"--jsonl", "--output-format", "json", "a", "b"},
+				stdin: []string{`{"a":1,"b":"x y"}`},
+			},
+			expectedStdout: []string{"[", `  {"a":"1","b":"x y"}`, "]"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --jsonl with invalid JSON exits with error",
+			input: input{
+				args:  []string{"--jsonl", ".name"},
+				stdin: []string{`{"name":`},
+			},
+			expectExitError: true,
+		},
+		{
+			name: "sel .name without --jsonl exits with error",
+			input: input{
+				args:  []string{".name"},
+				stdin: []string{`{"name":"alice"}`},
+			},
+			expectExitError: true,
+		},
+		{
+			name: "sel --csv --header --output-format jsonl --infer-types prints objects keyed by header",
+			input: input{
+				args:  []string{"--csv", "--header", "--output-format", "jsonl", "--infer-types", "id", "name", "=( $id * 10 )"},
+				stdin: []string{"id,name,ok", "1,alice,true", `2,"b ""o"" b",false`},
+			},
+			expectedStdout: []string{
+				`{"id":1,"name":"alice","=( $id * 10 )":10}`,
+				`{"id":2,"name":"b \"o\" b","=( $id * 10 )":20}`,
+			},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --output-format json 1 2:3 prints an array of arrays",
+			input: input{
+				args:  []string{"--output-format", "json", "1", "2:3"},
+				stdin: []string{"a b c", "1 2 3"},
+			},
+			expectedStdout: []string{"[", `  ["a","b","c"],`, `  ["1","2","3"]`, "]"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --output-format jsonl --json-object keys by query",
+			input: input{
+				args:  []string{"--output-format", "jsonl", "--json-object", "1", "2:3", "1|upper"},
+				stdin: []string{"a b c"},
+			},
+			expectedStdout: []string{`{"1":"a","2:3":["b","c"],"1|upper":"A"}`},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --output-format yaml exits with error",
+			input: input{
+				args:  []string{"--output-format", "yaml", "1"},
+				stdin: []string{"a b c"},
+			},
+			expectExitError: true,
+		},
+		{
+			name: "sel --csv --header --output-format table prints a box table",
+			input: input{
+				args:  []string{"--csv", "--header", "--output-format", "table", "name", "qty"},
+				stdin: []string{"id,name,qty", "1,りんご,12", "2,banana,3"},
+			},
+			expectedStdout: []string{
+				"┌────────┬─────┐",
+				"│ name   │ qty │",
+				"├────────┼─────┤",
+				"│ りんご │  12 │",
+				"│ banana │   3 │",
+				"└────────┴─────┘",
+			},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --header --output-format markdown right-aligns numeric columns",
+			input: input{
+				args:  []string{"--header", "--output-format", "markdown", "name", "qty"},
+				stdin: []string{"name qty", "a|b 10", "c 2"},
+			},
+			expectedStdout: []string{
+				"| name | qty |",
+				"|:-----|----:|",
+				"| a\\|b |  10 |",
+				"| c    |   2 |",
+			},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --output-format github --table-sample 1 streams rows after the first",
+			input: input{
+				args:  []string{"--output-format", "github", "--table-sample", "1", "1", "2"},
+				stdin: []string{"a b", "ccccc d"},
+			},
+			expectedStdout: []string{
+				"| 1   | 2   |",
+				"|-----|-----|",
+				"| a   | b   |",
+				"| ccccc | d   |",
+			},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --ltsv host status /^req/ prints LTSV with labels",
+			input: input{
+				args: []string{"--ltsv", "host", "status", "/^req/"},
+				stdin: []string{
+					"host:127.0.0.1\treq:GET / HTTP/1.1\tstatus:200\treqtime:0.1",
+					"status:404\thost:10.0.0.2\treq:GET /x HTTP/1.1",
+				},
+			},
+			expectedStdout: []string{
+				"host:127.0.0.1\tstatus:200\treq:GET / HTTP/1.1\treqtime:0.1",
+				"host:10.0.0.2\tstatus:404\treq:GET /x HTTP/1.1",
+			},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --ltsv -D ' ' -E - --where uses labels for each line",
+			input: input{
+				args: []string{"--ltsv", "-D", " ", "-E", "-", "--where", "$status >= 400", "host", "2", "reqtime"},
+				stdin: []string{
+					"host:a\tstatus:500\treqtime:0.1",
+					"host:b\tstatus:200\treqtime:0.2",
+					"status:404\thost:c",
+				},
+			},
+			expectedStdout: []string{"a 500 0.1", "c c -"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --logfmt level msg /^dur/ prints values with quotes removed",
+			input: input{
+				args: []string{"--logfmt", "level", "msg", "/^dur/"},
+				stdin: []string{
+					`level=info msg="hello world" dur=3ms`,
+					`dur_ms=5 level=error msg="say \"hi\""`,
+				},
+			},
+			expectedStdout: []string{"info hello world 3ms", `error say "hi" 5`},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --logfmt --output-format logfmt -M prints key=value pairs",
+			input: input{
+				args:  []string{"--logfmt", "--output-format", "logfmt", "-M", "--where", `$level == "error"`, "msg", "err"},
+				stdin: []string{`level=info msg=ok`, `level=error msg="disk full" err="no space"`, `level=error msg=x`},
+			},
+			expectedStdout: []string{`msg="disk full" err="no space"`, `msg=x`},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --quoted -- 1 -1 2 removes quotes and escapes",
+			input: input{
+				args:  []string{"--quoted", "--", "1", "-1", "2"},
+				stdin: []string{`"New York" 'a b' 42`, `say\ hi "it's" x"y z"`},
+			},
+			expectedStdout: []string{"New York 42 a b", "say hi xy z it's"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --quoted --quote-chars ` --no-escape keeps backslashes",
+			input: input{
+				args:  []string{"--quoted", "--quote-chars", "`", "--no-escape", "--output-format", "jsonl", "1:"},
+				stdin: []string{"`C:\\Program Files` \"a b\""},
+			},
+			expectedStdout: []string{`["C:\\Program Files","\"a","b\""]`},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --csv-delimiter ; --csv-comment # prints semicolon CSV",
+			input: input{
+				args:  []string{"--csv-delimiter", ";", "--csv-comment", "#", "--header", "name", "price"},
+				stdin: []string{"# exported", "id;name;price", `1;"a;b";1,5`, "2;c;2,0"},
+			},
+			expectedStdout: []string{`"a;b";1,5`, "c;2,0"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --csv with lazy quotes, leading spaces and ragged rows",
+			input: input{
+				args:  []string{"--csv", "--csv-lazy-quotes", "--csv-trim-leading-space", "--csv-fields-per-record", "-1", "-D", "|", "-E", "-", "1", "3"},
+				stdin: []string{`a, say "hi", c`, `d,  e`},
+			},
+			expectedStdout: []string{`a|c`, `d|-`},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --csv --keep-header --where --rows keeps the header line",
+			input: input{
+				args:  []string{"--csv", "--keep-header", "--where", "$3 > 100", "--rows", "-2:", "1", "3"},
+				stdin: []string{"id,name,score", "1,alice,120", "2,bob,80", "3,carol,150", "4,dave,90"},
+			},
+			expectedStdout: []string{"id,score", "3,150"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --header --emit-header prints names in header and queries",
+			input: input{
+				args:  []string{"--header", "--emit-header", "name", "1", "=( $score * 2 )"},
+				stdin: []string{"id name score", "1 alice 120", "2 bob 80"},
+			},
+			expectedStdout: []string{"name id =( $score * 2 )", "alice 1 240", "bob 2 160"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --header --emit-header --where '$score > 1000' name prints the header without rows",
+			input: input{
+				args:  []string{"--header", "--emit-header", "--where", "$score > 1000", "name"},
+				stdin: []string{"id name score", "1 alice 120", "2 bob 80"},
+			},
+			expectedStdout: []string{"name"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --emit-header --rows 10 1 3 prints queries as the header without rows",
+			input: input{
+				args:  []string{"--emit-header", "--rows", "10", "1", "3"},
+				stdin: []string{"a b c"},
+			},
+			expectedStdout: []string{"1 3"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel -z -d / -- -1 reads and writes NUL separated records",
+			input: input{
+				args:  []string{"-z", "-d", "/", "--", "-1"},
+				stdin: []string{"./a b/c.log\x00./new\nline.log\x00"},
+			},
+			expectedStdout: []string{"c.log\x00new", "line.log\x00"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --record-separator '' splits paragraphs",
+			input: input{
+				args:  []string{"--record-separator", "", "-a", "--output-record-separator", ";", "--", "1", "-1"},
+				stdin: []string{"", "alice 20", "tokyo", "", "", "bob 30", "osaka"},
+			},
+			expectedStdout: []string{"alice tokyo;bob osaka;"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --record-separator '' splits columns on newlines",
+			input: input{
+				args:  []string{"--record-separator", "", "2", "3"},
+				stdin: []string{"alice 20", "tokyo", "", "bob 30", "osaka"},
+			},
+			expectedStdout: []string{"20 tokyo", "30 osaka"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --record-separator /regexp/ --output-format jsonl",
+			input: input{
+				args:  []string{"--record-separator", `/\n-{3,}\n/`, "--output-format", "jsonl", "1", "2"},
+				stdin: []string{"a 1", "---", "b 2", "-----", "c 3"},
+			},
+			expectedStdout: []string{`["a","1"]`, `["b","2"]`, `["c","3"]`},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --record-start --where selects whole log events",
+			input: input{
+				args: []string{"--record-start", `/^\d{4}-\d\d-\d\d/`, "--record-join", " | ", "--where", `$2 == "ERROR"`, "1", "0"},
+				stdin: []string{
+					"2024-01-01 INFO started",
+					"2024-01-01 ERROR boom",
+					"java.lang.IllegalStateException: boom",
+					"\tat a.b(C.java:1)",
+					"2024-01-02 INFO ok",
+				},
+			},
+			expectedStdout: []string{"2024-01-01 2024-01-01 ERROR boom | java.lang.IllegalStateException: boom | \tat a.b(C.java:1)"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel -a --continuation joins wrapped lines with newline",
+			input: input{
+				args:  []string{"-a", "--continuation", `^\s`, "--output-format", "jsonl", "2:"},
+				stdin: []string{"a 1", "  wrapped", "b 2"},
+			},
+			expectedStdout: []string{`["1","wrapped"]`, `["2"]`},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --csv --input-encoding shift_jis reads Shift_JIS CSV",
+			input: input{
+				args:  []string{"--csv", "--header", "--input-encoding", "shift_jis", "--where", `$都市 == "東京"`, "名前"},
+				stdin: []string{"\x96\xbc\x91O,\x93s\x8es", "\x91\xbe\x98Y,\x93\x8c\x8b\x9e", "\x89\xd4\x8eq,\x91\xe5\x8d\xe3"},
+			},
+			expectedStdout: []string{"太郎"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --output-encoding euc-jp strips BOM of input",
+			input: input{
+				args:  []string{"--header", "--output-encoding", "euc-jp", "name", "id"},
+				stdin: []string{"\xEF\xBB\xBFid name", "1 太郎"},
+			},
+			expectedStdout: []string{"\xc2\xc0\xcf\xba 1"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel -g 1 0[1:5] slices the whole line after splitting",
+			input: input{
+				args:  []string{"-g", "-d", " ", "1", "0[1:5]"},
+				stdin: []string{"abc def ghi"},
+			},
+			expectedStdout: []string{"abc abc d"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --csv --header name id prints name and id as CSV without header",
+			input: input{
+				args:  []string{"--csv", "--header", "name", "id"},
+				stdin: []string{"id,name,status", "1,alice,ok", "2,bob,ng"},
+			},
+			expectedStdout: []string{"alice,1", "bob,2"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --header name:-1 prints from name to last column",
+			input: input{
+				args:  []string{"--header", "name:-1"},
+				stdin: []string{"id name age status", "1 alice 20 ok", "2 bob 30 ng"},
+			},
+			expectedStdout: []string{"alice 20 ok", "bob 30 ng"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --csv --header id @/^metric_/ prints id and metric columns",
+			input: input{
+				args:  []string{"--csv", "--header", "id", "@/^metric_/"},
+				stdin: []string{"id,metric_a,name,metric_b", "1,10,alice,20", "2,30,bob,40"},
+			},
+			expectedStdout: []string{"1,10,20", "2,30,40"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+		{
+			name: "sel --header unknown exits with error",
+			input: input{
+				args:  []string{"--header", "unknown"},
+				stdin: []string{"id name", "1 alice"},
+			},
+			expectExitError: true,
+		},
+		{
+			name: "sel name without --header exits with error",
+			input: input{
+				args:  []string{"name"},
+				stdin: []string{"id name", "1 alice"},
+			},
+			expectExitError: true,
+		},
+		{
+			name: "sel name without --header exits with error on empty input",
+			input: input{
+				args:  []string{"name"},
+				stdin: []string{},
+			},
+			expectExitError: true,
+		},
+		{
+			name: "sel 1,2 exits with error",
+			input: input{
+				args:  []string{"1,2"},
+				stdin: []string{"a b"},
+			},
+			expectExitError: true,
+		},
+		{
+			name: "sel --jsonl name id resolves names by keys",
+			input: input{
+				args:  []string{"--jsonl", "name", "id"},
+				stdin: []string{`{"id":1,"name":"alice"}`, `{"name":"bob","id":2}`},
+			},
+			expectedStdout: []string{"alice 1", "bob 2"},
+			expectedStderr: []string{""},
+			expectedError:  nil,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			stdout, stderr, err := runSel(selPath, testcase.input.args, testcase.input.stdin)
+			if testcase.expectExitError {
+				as.Error(err, "エラーで終了するべき")
+			} else if testcase.expectedError != nil {
+				as.Equal(err, testcase.expectedError, "エラー内容が一致するべき")
+			} else {
+				as.NoError(err, "エラーなしで終了するべき")
+				as.Equal(testcase.expectedStdout, stdout, "標準出力が一致するべき")
+				as.Equal(testcase.expectedStderr, stderr, "標準エラー出力が一致するべき")
+			}
+		})
+	}
+}
+
+func Test_E2E_HeaderOnce(t *testing.T) {
+	selPath := filepath.Join(ProjectRoot(), "dist", "sel")
+
+	dir := t.TempDir()
+	var files []string
+	for i, content := range []string{"id,name\n1,alice\n", "id,name\n2,bob\n"} {
+		file := filepath.Join(dir, fmt.Sprintf("%d.csv", i))
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+		files = append(files, "-f", file)
+	}
+
+	for _, flag := range []string{"--keep-header", "--emit-header"} {
+		t.Run(flag, func(t *testing.T) {
+			args := append([]string{"--csv", "--header", flag, "name", "id"}, files...)
+			stdout, _, err := runSel(selPath, args, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"name,id", "alice,1", "bob,2"}, stdout, "ヘッダー行は1回だけ書き出すべき")
+		})
+	}
+}
+
+func Test_E2E_Compressed(t *testing.T) {
+	selPath := filepath.Join(ProjectRoot(), "dist", "sel")
+
+	gzipped := &bytes.Buffer{}
+	gw := gzip.NewWriter(gzipped)
+	_, err := gw.Write([]byte("id,name\n1,alice\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, gw.Close())
+
+	dir := t.TempDir()
+	gz := filepath.Join(dir, "1.csv.gz")
+	plain := filepath.Join(dir, "2.csv")
+	assert.NoError(t, os.WriteFile(gz, gzipped.Bytes(), 0o644))
+	assert.NoError(t, os.WriteFile(plain, []byte("id,name\n2,bob\n"), 0o644))
+
+	t.Run("files", func(t *testing.T) {
+		stdout, _, err := runSel(selPath, []string{"--csv", "--header", "--keep-header", "name", "id", "-f", gz, "-f", plain}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"name,id", "alice,1", "bob,2"}, stdout, "圧縮されたファイルも展開して読むべき")
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		stdout, _, err := runSel(selPath, []string{"--csv", "2"}, []string{gzipped.String()})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"name", "alice"}, stdout, "標準入力も展開して読むべき")
+	})
+
+	t.Run("--no-decompress", func(t *testing.T) {
+		stdout, _, err := runSel(selPath, []string{"--no-decompress", "0"}, []string{gzipped.String()})
+		require.NoError(t, err)
+		require.NotEmpty(t, stdout)
+		assert.True(t, strings.HasPrefix(stdout[0], "\x1f\x8b"), "展開せずにそのまま読むべき")
+	})
+}
+
+func Test_E2E_Archive(t *testing.T) {
+	selPath := filepath.Join(ProjectRoot(), "dist", "sel")
+
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, m := range []struct{ name, content string }{
+		{"reports/1.csv", "id,name\n1,alice\n"},
+		{"reports/2.csv", "name,id\nbob,2\n"},
+		{"readme.txt", "read me\n"},
+	} {
+		w, err := zw.Create(m.name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(m.content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+
+	bundle := filepath.Join(t.TempDir(), "bundle.zip")
+	assert.NoError(t, os.WriteFile(bundle, buf.Bytes(), 0o644))
+
+	stdout, _, err := runSel(selPath, []string{"--csv", "--header", "--emit-header", "id", "name", "-f", bundle + ":reports/*.csv"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id,name", "1,alice", "2,bob"}, stdout, "アーカイブの中のファイルごとにヘッダーを読むべき")
+
+	_, _, err = runSel(selPath, []string{"1", "-f", bundle + ":*.json"}, nil)
+	assert.Error(t, err, "マッチするファイルがなければエラーになるべき")
+
+	tarball := &bytes.Buffer{}
+	gw := gzip.NewWriter(tarball)
+	tw := tar.NewWriter(gw)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "1.txt", Mode: 0o644, Size: 8, Typeflag: tar.TypeReg}))
+	_, err = tw.Write([]byte("a b\nc d\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+
+	logs := filepath.Join(t.TempDir(), "logs.tar.gz")
+	assert.NoError(t, os.WriteFile(logs, tarball.Bytes(), 0o644))
+
+	stdout, _, err = runSel(selPath, []string{"2", "-f", logs}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "d"}, stdout, ".tar.gz は展開して中のファイルを読むべき")
+
+	_, _, err = runSel(selPath, []string{"--no-decompress", "2", "-f", logs}, nil)
+	assert.Error(t, err, "--no-decompress のときは .tar.gz を展開しないので tar として読めないべき")
 }